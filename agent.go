@@ -66,3 +66,36 @@ func (r Agent) Call(options polycode.TaskOptions, input polycode.AgentInput) pol
 		error:   output.Error,
 	}
 }
+
+// Start calls the agent as a child task without waiting for it and returns a handle to the task
+func (r Agent) Start(options polycode.TaskOptions, input polycode.AgentInput) (TaskHandle, error) {
+	req := ExecServiceRequest{
+		EnvId:         r.envId,
+		Service:       "agent-service",
+		Method:        "CallAgent",
+		Options:       options.WithSequenceKey(r.agent + ":" + input.SessionKey),
+		FireAndForget: true,
+		Headers: map[string]string{
+			AgentNameHeader: r.agent,
+		},
+		Input: input,
+	}
+
+	output, err := r.serviceClient.ExecService(r.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: exec task error: %v\n", err)
+		return TaskHandle{}, ErrTaskExecError.Wrap(err)
+	}
+
+	fmt.Printf("client: exec task output: %v\n", output)
+	if output.IsError {
+		return TaskHandle{}, output.Error
+	}
+
+	return TaskHandle{
+		ctx:           r.ctx,
+		sessionId:     r.sessionId,
+		taskId:        output.TaskId,
+		serviceClient: r.serviceClient,
+	}, nil
+}
@@ -2,7 +2,9 @@ package runtime
 
 import (
 	"context"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cloudimpl/polycode-sdk-go"
@@ -59,6 +61,14 @@ func (m *mockServiceClient) ExecService(sessionId string, req ExecServiceRequest
 // --- Unused interface methods: return zero values so the type compiles ---
 
 func (*mockServiceClient) StartApp(req StartAppRequest) error { return nil }
+func (*mockServiceClient) StopApp(req StopAppRequest) error   { return nil }
+func (*mockServiceClient) Ping(context.Context) error         { return nil }
+func (*mockServiceClient) ListVersionMarkers(VersionMarkersRequest) (VersionMarkersResponse, error) {
+	return VersionMarkersResponse{}, nil
+}
+func (*mockServiceClient) ExecServiceBatch(string, ExecServiceBatchRequest) (ExecServiceBatchResponse, error) {
+	return ExecServiceBatchResponse{}, nil
+}
 func (*mockServiceClient) ExecApp(string, ExecAppRequest) (ExecAppResponse, error) {
 	return ExecAppResponse{}, nil
 }
@@ -86,15 +96,52 @@ func (*mockServiceClient) UnsafePutItem(string, UnsafePutRequest) error { return
 func (*mockServiceClient) GetFile(string, GetFileRequest) (GetFileResponse, error) {
 	return GetFileResponse{}, nil
 }
-func (*mockServiceClient) GetFileDownloadLink(string, GetFileRequest) (GetLinkResponse, error) {
+func (*mockServiceClient) GetFileStream(string, GetFileRequest) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (*mockServiceClient) GetFileDownloadLink(string, GetDownloadLinkRequest) (GetLinkResponse, error) {
 	return GetLinkResponse{}, nil
 }
 func (*mockServiceClient) PutFile(string, PutFileRequest) error { return nil }
+func (*mockServiceClient) PutFileStream(string, PutFileRequest, io.Reader) error {
+	return nil
+}
 func (*mockServiceClient) GetFileUploadLink(string, GetUploadLinkRequest) (GetLinkResponse, error) {
 	return GetLinkResponse{}, nil
 }
 func (*mockServiceClient) DeleteFile(string, DeleteFileRequest) error { return nil }
 func (*mockServiceClient) RenameFile(string, RenameFileRequest) error { return nil }
+func (*mockServiceClient) CopyFile(string, CopyFileRequest) error     { return nil }
+func (*mockServiceClient) StatFile(string, StatFileRequest) (StatFileResponse, error) {
+	return StatFileResponse{}, nil
+}
+func (*mockServiceClient) PromoteFile(string, PromoteFileRequest) error { return nil }
+func (*mockServiceClient) SetFolderVersioning(string, SetFolderVersioningRequest) error {
+	return nil
+}
+func (*mockServiceClient) ListFileVersions(string, ListFileVersionsRequest) (ListFileVersionsResponse, error) {
+	return ListFileVersionsResponse{}, nil
+}
+func (*mockServiceClient) RestoreFileVersion(string, RestoreFileVersionRequest) error { return nil }
+func (*mockServiceClient) UndeleteFile(string, UndeleteFileRequest) error             { return nil }
+func (*mockServiceClient) CreateMultipartUpload(string, CreateMultipartUploadRequest) (CreateMultipartUploadResponse, error) {
+	return CreateMultipartUploadResponse{}, nil
+}
+func (*mockServiceClient) GetPartUploadLink(string, GetPartUploadLinkRequest) (GetLinkResponse, error) {
+	return GetLinkResponse{}, nil
+}
+func (*mockServiceClient) PutFilePart(string, PutFilePartRequest, io.Reader) (UploadedPart, error) {
+	return UploadedPart{}, nil
+}
+func (*mockServiceClient) ListFileParts(string, ListFilePartsRequest) (ListFilePartsResponse, error) {
+	return ListFilePartsResponse{}, nil
+}
+func (*mockServiceClient) CompleteMultipartUpload(string, CompleteMultipartUploadRequest) error {
+	return nil
+}
+func (*mockServiceClient) AbortMultipartUpload(string, AbortMultipartUploadRequest) error {
+	return nil
+}
 func (*mockServiceClient) ListFile(string, ListFilePageRequest) (ListFilePageResponse, error) {
 	return ListFilePageResponse{}, nil
 }
@@ -103,9 +150,19 @@ func (*mockServiceClient) EmitSignal(string, SignalEmitRequest) error     { retu
 func (*mockServiceClient) WaitForSignal(string, SignalWaitRequest) (SignalWaitResponse, error) {
 	return SignalWaitResponse{}, nil
 }
+func (*mockServiceClient) SelectSignal(string, SignalSelectRequest) (SignalSelectResponse, error) {
+	return SignalSelectResponse{}, nil
+}
 func (*mockServiceClient) EmitRealtimeEvent(string, RealtimeEventEmitRequest) error { return nil }
-func (*mockServiceClient) AcquireLock(string, AcquireLockRequest) error             { return nil }
-func (*mockServiceClient) ReleaseLock(string, ReleaseLockRequest) error             { return nil }
+func (*mockServiceClient) AwaitTask(string, TaskAwaitRequest) (TaskAwaitResponse, error) {
+	return TaskAwaitResponse{}, nil
+}
+func (*mockServiceClient) GetTaskStatus(string, TaskStatusRequest) (TaskStatusResponse, error) {
+	return TaskStatusResponse{}, nil
+}
+func (*mockServiceClient) CancelTask(string, TaskCancelRequest) error   { return nil }
+func (*mockServiceClient) AcquireLock(string, AcquireLockRequest) error { return nil }
+func (*mockServiceClient) ReleaseLock(string, ReleaseLockRequest) error { return nil }
 func (*mockServiceClient) IncrementCounter(string, IncrementCounterRequest) (IncrementCounterResponse, error) {
 	return IncrementCounterResponse{}, nil
 }
@@ -2,39 +2,245 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
+// DefaultDrainTimeout bounds how long Start waits for in-flight invocations after a shutdown signal
+const DefaultDrainTimeout = 30 * time.Second
+
+// DefaultDrainDelay is how long the server keeps accepting requests while reporting unready, so
+// readiness probes take the app out of rotation before the listener closes
+const DefaultDrainDelay = 5 * time.Second
+
+// ShutdownConfig controls the graceful shutdown of the api server, durations are in milliseconds
+type ShutdownConfig struct {
+	// DrainDelay is the time between reporting unready and closing the listener, 0 uses DefaultDrainDelay
+	// and a negative value closes the listener at once
+	DrainDelay int64 `json:"drainDelay"`
+	// DrainTimeout bounds the wait for in-flight invocations after the listener closes, 0 uses DefaultDrainTimeout
+	DrainTimeout int64 `json:"drainTimeout"`
+}
+
+var errDraining = errors.New("app is shutting down")
+
 type ApiServerListener interface {
 	RunService(ctx context.Context, event ServiceStartEvent) (evt ServiceCompleteEvent)
 	RunApi(ctx context.Context, event ApiStartEvent) (evt ApiCompleteEvent)
 }
 
+// ApiServerStopListener is implemented by listeners that need to tell the sidecar the app is stopping
+type ApiServerStopListener interface {
+	Stop() error
+}
+
 type ApiServer struct {
-	listener  ApiServerListener
-	ginEngine *gin.Engine
+	listener     ApiServerListener
+	ginEngine    *gin.Engine
+	limiter      *ConcurrencyLimiter
+	drainDelay   time.Duration
+	drainTimeout time.Duration
+	draining     atomic.Bool
+	mu           sync.Mutex
+	httpServer   *http.Server
+}
+
+// NewApiServer creates the server the sidecar invokes the app through, limited by the concurrency config
+// of env and shut down according to its shutdown config
+func NewApiServer(listener ApiServerListener, env ClientEnv) *ApiServer {
+	return &ApiServer{
+		listener:     listener,
+		limiter:      NewConcurrencyLimiter(env.Concurrency),
+		drainDelay:   time.Duration(env.Shutdown.DrainDelay) * time.Millisecond,
+		drainTimeout: time.Duration(env.Shutdown.DrainTimeout) * time.Millisecond,
+	}
 }
 
-func (s *ApiServer) Start(port int) {
+// Start serves until ctx is cancelled or the process receives SIGTERM or SIGINT, then shuts the
+// server down gracefully. It returns nil after a graceful shutdown.
+func (s *ApiServer) Start(ctx context.Context, port int) error {
 	// Create a Gin router
 	s.ginEngine = gin.Default()
 
 	s.ginEngine.GET("/v1/health", s.invokeHealthCheck)
+	s.ginEngine.GET("/v1/health/live", s.invokeHealthCheck)
+	s.ginEngine.GET("/v1/health/ready", s.invokeReadyCheck)
+	s.ginEngine.GET("/v1/health/startup", s.invokeStartupCheck)
 	s.ginEngine.POST("/v1/invoke/api", s.invokeApiHandler)
 	s.ginEngine.POST("/v1/invoke/service", s.invokeServiceHandler)
+	s.ginEngine.POST("/v1/invoke/cancel", s.invokeCancelHandler)
 
-	// Start the Gin server
-	err := s.ginEngine.Run(fmt.Sprintf(":%d", port))
-	if err != nil {
-		log.Fatalf("Failed to start api server: %s", err.Error())
+	if _, ok := s.listener.(VersionReporter); ok {
+		s.ginEngine.GET("/v1/versions", s.invokeVersionReport)
+	}
+
+	// presigned links of a local file store are served by the app itself
+	if provider, ok := s.listener.(LocalFileStoreProvider); ok && provider.LocalFileStore() != nil {
+		s.ginEngine.Any(LocalFileLinkPath+"/*key", gin.WrapH(provider.LocalFileStore()))
+	}
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: s.ginEngine,
+	}
+
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.mu.Unlock()
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		// Start the Gin server
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		log.Printf("Failed to start api server: %s", err.Error())
+		return err
+	case <-ctx.Done():
+		log.Println("api server: shutdown requested")
+	}
+
+	drainTimeout := s.drainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = DefaultDrainTimeout
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.getDrainDelay()+drainTimeout)
+	defer cancel()
+	return s.Shutdown(shutdownCtx)
+}
+
+func (s *ApiServer) getDrainDelay() time.Duration {
+	if s.drainDelay == 0 {
+		return DefaultDrainDelay
+	}
+	if s.drainDelay < 0 {
+		return 0
+	}
+	return s.drainDelay
+}
+
+// Shutdown marks the server unready, notifies the sidecar that the app is stopping, keeps serving for
+// the drain delay so readiness probes see the app unready, then closes the listener and drains in-flight
+// invocations until ctx expires, after which the remaining invocations are cancelled
+func (s *ApiServer) Shutdown(ctx context.Context) error {
+	if s.draining.Swap(true) {
+		return nil
+	}
+	log.Println("api server: draining")
+
+	if stopListener, ok := s.listener.(ApiServerStopListener); ok {
+		err := stopListener.Stop()
+		if err != nil {
+			log.Printf("api server: failed to notify sidecar of stop: %s", err.Error())
+		}
+	}
+
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	if delay := s.getDrainDelay(); delay > 0 {
+		log.Printf("api server: unready, closing listener in %s", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
 	}
+
+	err := httpServer.Shutdown(ctx)
+	if err == nil {
+		log.Println("api server: drained")
+		return nil
+	}
+
+	log.Printf("api server: drain incomplete, cancelling %d invocations: %s", Invocations.Count(), err.Error())
+	Invocations.CancelAll(errDraining.Error())
+	_ = httpServer.Close()
+	return err
 }
 
 func (s *ApiServer) invokeHealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	var stats ConcurrencyStats
+	if s.limiter != nil {
+		stats = s.limiter.Stats()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "inFlight": stats.InFlight, "queued": stats.Queued})
+}
+
+func (s *ApiServer) invokeReadyCheck(c *gin.Context) {
+	report := Health.Check(c)
+	if s.draining.Load() {
+		report.Status = HealthStatusFail
+		report.Checks["draining"] = HealthCheckResult{
+			Status: HealthStatusFail,
+			Error:  errDraining.Error(),
+		}
+	}
+
+	if report.Status != HealthStatusOk {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (s *ApiServer) invokeStartupCheck(c *gin.Context) {
+	if !Health.IsStarted() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": HealthStatusFail})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": HealthStatusOk})
+}
+
+func (s *ApiServer) invokeVersionReport(c *gin.Context) {
+	report, err := s.listener.(VersionReporter).VersionReport()
+	if err != nil {
+		fmt.Printf("version report failed %s\n", err.Error())
+		c.JSON(http.StatusBadGateway, ErrorEvent{Error: ErrSidecarClientFailed.With(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// acquire waits for a limiter slot while the sidecar request is open, the gin context itself is never
+// done so the request context is used
+func (s *ApiServer) acquire(c *gin.Context, keys ...string) (func(), error) {
+	if s.draining.Load() {
+		return nil, errDraining
+	}
+
+	if s.limiter == nil {
+		return func() {}, nil
+	}
+	return s.limiter.Acquire(c.Request.Context(), keys...)
 }
 
 func (s *ApiServer) invokeApiHandler(c *gin.Context) {
@@ -45,8 +251,11 @@ func (s *ApiServer) invokeApiHandler(c *gin.Context) {
 	if err := c.ShouldBindJSON(&input); err != nil {
 		output = ErrorToApiComplete(ErrInternal.Wrap(err))
 		fmt.Printf("api task failed %s\n", err.Error())
+	} else if release, err := s.acquire(c, ApiLimitKey); err != nil {
+		output = OverloadedToApiComplete(err)
+		fmt.Printf("api task rejected %s\n", err.Error())
 	} else {
-		output = s.listener.RunApi(c, input)
+		output = s.runApi(c, input, release)
 		fmt.Println("api task success")
 	}
 
@@ -61,10 +270,44 @@ func (s *ApiServer) invokeServiceHandler(c *gin.Context) {
 	if err := c.ShouldBindJSON(&input); err != nil {
 		output = ErrorToServiceComplete(ErrInternal.Wrap(err), "")
 		fmt.Printf("service task failed %s\n", err.Error())
+	} else if release, err := s.acquire(c, input.Service, input.Service+"."+input.Method); err != nil {
+		output = OverloadedToServiceComplete(err)
+		fmt.Printf("service task rejected %s\n", err.Error())
 	} else {
-		output = s.listener.RunService(c, input)
+		output = s.runService(c, input, release)
 		fmt.Println("service task success")
 	}
 
 	c.JSON(http.StatusOK, output)
 }
+
+// runApi runs the invocation holding the acquired slot, the slot is released even if the listener panics
+func (s *ApiServer) runApi(c *gin.Context, input ApiStartEvent, release func()) ApiCompleteEvent {
+	defer release()
+	return s.listener.RunApi(c, input)
+}
+
+func (s *ApiServer) runService(c *gin.Context, input ServiceStartEvent, release func()) ServiceCompleteEvent {
+	defer release()
+	return s.listener.RunService(c, input)
+}
+
+func (s *ApiServer) invokeCancelHandler(c *gin.Context) {
+	var input CancelInvocationRequest
+
+	fmt.Println("cancel task received")
+	if err := c.ShouldBindJSON(&input); err != nil {
+		fmt.Printf("cancel task failed %s\n", err.Error())
+		c.JSON(http.StatusBadRequest, ErrorEvent{Error: ErrBadRequest.Wrap(err)})
+		return
+	}
+
+	reason := input.Reason
+	if reason == "" {
+		reason = "cancelled by platform"
+	}
+
+	cancelled := Invocations.Cancel(input.SessionId, reason)
+	fmt.Printf("cancel task %s, running: %v\n", input.SessionId, cancelled)
+	c.JSON(http.StatusOK, CancelInvocationResponse{Cancelled: cancelled})
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/cloudimpl/polycode-sdk-go"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -46,6 +49,42 @@ func (m mockRuntime) RunService(ctx context.Context, input ServiceStartEvent) Se
 	}
 }
 
+// blockingService holds its invocations until released, to keep concurrency slots taken
+type blockingService struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s blockingService) GetName() string {
+	return "blocking"
+}
+
+func (s blockingService) GetDescription(method string) (string, error) {
+	return "", nil
+}
+
+func (s blockingService) GetInputType(method string) (any, error) {
+	return &map[string]any{}, nil
+}
+
+func (s blockingService) GetOutputType(method string) (any, error) {
+	return &map[string]any{}, nil
+}
+
+func (s blockingService) IsWorkflow(method string) bool {
+	return false
+}
+
+func (s blockingService) ExecuteService(ctx polycode.ServiceContext, method string, input any) (any, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	return "done", nil
+}
+
+func (s blockingService) ExecuteWorkflow(ctx polycode.WorkflowContext, method string, input any) (any, error) {
+	return nil, errors.New("not a workflow")
+}
+
 // ---------- Helper: Get Free Port ----------
 
 func getFreePort() (int, error) {
@@ -63,8 +102,8 @@ func startTestServer(t *testing.T, r ApiServerListener) string {
 	port, err := getFreePort()
 	assert.NoError(t, err)
 
-	server := &ApiServer{listener: r}
-	go server.Start(port)
+	server := NewApiServer(r, ClientEnv{})
+	go server.Start(context.Background(), port)
 
 	url := fmt.Sprintf("http://localhost:%d", port)
 
@@ -189,3 +228,257 @@ func TestInvokeServiceHandler_RealServer(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Contains(t, string(respBody), `"result":"success"`)
 }
+
+func TestInvokeCancelHandler_RealServer(t *testing.T) {
+	baseURL := startTestServer(t, mockRuntime{})
+
+	ctx, release := Invocations.Register(context.Background(), "sess-cancel")
+	defer release()
+
+	data, _ := json.Marshal(CancelInvocationRequest{SessionId: "sess-cancel", Reason: "user abort"})
+	resp, err := http.Post(baseURL+"/v1/invoke/cancel", "application/json", bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(respBody), `"cancelled":true`)
+	assert.Error(t, ctx.Err())
+	assert.EqualError(t, context.Cause(ctx), "user abort")
+
+	// sidecar calls of the cancelled session are aborted unless they run detached
+	assert.ErrorIs(t, Invocations.Context("sess-cancel").Err(), context.Canceled)
+
+	undetach := Invocations.Detach("sess-cancel")
+	assert.NoError(t, Invocations.Context("sess-cancel").Err())
+	undetach()
+	assert.Error(t, Invocations.Context("sess-cancel").Err())
+}
+
+func TestShutdown_RealServer(t *testing.T) {
+	port, err := getFreePort()
+	assert.NoError(t, err)
+
+	server := NewApiServer(mockRuntime{}, ClientEnv{Shutdown: ShutdownConfig{DrainDelay: 300}})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(context.Background(), port)
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d", port)
+	ready := false
+	for i := 0; i < 30 && !ready; i++ {
+		resp, err := http.Get(url + "/v1/health/live")
+		if err == nil {
+			ready = resp.StatusCode == http.StatusOK
+			resp.Body.Close()
+		}
+		if !ready {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	assert.True(t, ready)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	// during the drain delay the listener stays open and reports unready, the probe client opens a
+	// connection per request like a kubelet probe
+	probe := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	unready := false
+	for i := 0; i < 20 && !unready; i++ {
+		resp, err := probe.Get(url + "/v1/health/ready")
+		if err == nil {
+			unready = resp.StatusCode == http.StatusServiceUnavailable
+			resp.Body.Close()
+		}
+		if !unready {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	assert.True(t, unready)
+	assert.NoError(t, <-shutdown)
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatalf("server did not stop")
+	}
+
+	_, err = http.Get(url + "/v1/health/live")
+	assert.Error(t, err)
+}
+
+func TestReadinessReport_RealServer(t *testing.T) {
+	baseURL := startTestServer(t, mockRuntime{})
+
+	health := Health
+	t.Cleanup(func() {
+		Health = health
+	})
+	Health = NewHealthRegistry()
+	assert.NoError(t, Health.Register("db", func(ctx context.Context) error {
+		return errors.New("db unreachable")
+	}))
+
+	resp, err := http.Get(baseURL + "/v1/health/startup")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	Health.MarkStarted()
+
+	resp, err = http.Get(baseURL + "/v1/health/ready")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Contains(t, string(body), `"handshake":{"status":"ok"`)
+	assert.Contains(t, string(body), `"error":"db unreachable"`)
+
+	resp, err = http.Get(baseURL + "/v1/health/startup")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestVersionReport_RealServer(t *testing.T) {
+	sidecar := httptest.NewServer(newMockBackendRouter())
+	defer sidecar.Close()
+
+	rt := ClientRuntime{
+		env:    ClientEnv{AppName: "shop"},
+		client: NewServiceClient(sidecar.URL),
+		versionMap: map[string][]VersionDescription{
+			"orders.Place": {{ChangeId: "new-tax", MinVersion: DefaultVersion, MaxVersion: 1}},
+		},
+	}
+	baseURL := startTestServer(t, rt)
+
+	resp, err := http.Get(baseURL + "/v1/versions")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report []VersionUsage
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, []VersionUsage{{
+		Method:             "orders.Place",
+		VersionDescription: VersionDescription{ChangeId: "new-tax", MinVersion: DefaultVersion, MaxVersion: 1},
+		InFlight:           []int{1},
+	}}, report)
+}
+
+func TestPing_HonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer sidecar.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := NewServiceClient(sidecar.URL).Ping(ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestServe_AppliesEnvConcurrency(t *testing.T) {
+	port, err := getFreePort()
+	assert.NoError(t, err)
+
+	health := Health
+	t.Cleanup(func() {
+		Health = health
+	})
+	Health = NewHealthRegistry()
+
+	rt := NewClientRuntime(ClientEnv{
+		AppName:     "testApp",
+		AppPort:     uint(port),
+		SidecarApi:  "http://127.0.0.1:1",
+		Concurrency: ConcurrencyConfig{MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: 100},
+		Shutdown:    ShutdownConfig{DrainDelay: -1},
+	})
+
+	service := blockingService{
+		entered: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	assert.NoError(t, rt.RegisterService(service))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- rt.Serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d", port)
+	ready := false
+	for i := 0; i < 30 && !ready; i++ {
+		resp, err := http.Get(url + "/v1/health/live")
+		if err == nil {
+			ready = resp.StatusCode == http.StatusOK
+			resp.Body.Close()
+		}
+		if !ready {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	assert.True(t, ready)
+
+	invoke := func(sessionId string) string {
+		data, _ := json.Marshal(ServiceStartEvent{SessionId: sessionId, Service: "blocking", Method: "Hold"})
+		resp, err := http.Post(url+"/v1/invoke/service", "application/json", bytes.NewBuffer(data))
+		if err != nil {
+			return err.Error()
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	first := make(chan string, 1)
+	go func() {
+		first <- invoke("sess-held")
+	}()
+	<-service.entered
+
+	// queued behind the held invocation until the queue timeout rejects it
+	assert.Contains(t, invoke("sess-rejected"), `"isRetryable":true`)
+
+	close(service.release)
+	assert.Contains(t, <-first, `"output":"done"`)
+}
+
+func TestAcquire_UsesRequestContext(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{MaxConcurrent: 1, MaxQueued: 1})
+	held, err := limiter.Acquire(context.Background())
+	assert.NoError(t, err)
+	defer held()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/invoke/service", nil).WithContext(ctx)
+
+	// a sidecar that gave up on the request does not keep its invocation queued
+	s := &ApiServer{limiter: limiter}
+	_, err = s.acquire(c, "orders")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(0), limiter.Stats().Queued)
+}
@@ -14,7 +14,7 @@ type App struct {
 	serviceClient ServiceClient
 }
 
-func (r App) RequestReply(options polycode.TaskOptions, method string, input any) Response {
+func (r App) RequestReply(options polycode.TaskOptions, method string, input any) polycode.Response {
 	req := ExecAppRequest{
 		EnvId:   r.envId,
 		AppName: r.appName,
@@ -42,6 +42,12 @@ func (r App) RequestReply(options polycode.TaskOptions, method string, input any
 }
 
 func (r App) Send(options polycode.TaskOptions, method string, input any) error {
+	_, err := r.Start(options, method, input)
+	return err
+}
+
+// Start starts the method of the app as a child task without waiting for it and returns a handle to the task
+func (r App) Start(options polycode.TaskOptions, method string, input any) (TaskHandle, error) {
 	req := ExecAppRequest{
 		EnvId:         r.envId,
 		AppName:       r.appName,
@@ -54,13 +60,18 @@ func (r App) Send(options polycode.TaskOptions, method string, input any) error
 	output, err := r.serviceClient.ExecApp(r.sessionId, req)
 	if err != nil {
 		fmt.Printf("client: exec task error: %v\n", err)
-		return ErrTaskExecError.Wrap(err)
+		return TaskHandle{}, ErrTaskExecError.Wrap(err)
 	}
 
 	fmt.Printf("client: exec task output: %v\n", output)
 	if output.IsError {
-		return output.Error
-	} else {
-		return nil
+		return TaskHandle{}, output.Error
 	}
+
+	return TaskHandle{
+		ctx:           r.ctx,
+		sessionId:     r.sessionId,
+		taskId:        output.TaskId,
+		serviceClient: r.serviceClient,
+	}, nil
 }
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+// appServiceClient answers app calls with a canned response and records the last request
+type appServiceClient struct {
+	*mockServiceClient
+	req ExecAppRequest
+	out ExecAppResponse
+	err error
+}
+
+func (c *appServiceClient) ExecApp(sessionId string, req ExecAppRequest) (ExecAppResponse, error) {
+	c.req = req
+	return c.out, c.err
+}
+
+func TestContext_AppRequestReply(t *testing.T) {
+	client := &appServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out:               ExecAppResponse{Output: "invoiced"},
+	}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var opts polycode.TaskOptions
+	var res polycode.Response = ctx.App("billing").RequestReply(opts, "Invoice", 10)
+
+	var out string
+	if err := res.Get(&out); err != nil || out != "invoiced" {
+		t.Fatalf("unexpected result %q (err=%v)", out, err)
+	}
+	if client.req.AppName != "billing" || client.req.Method != "Invoice" || client.req.EnvId != "" || client.req.FireAndForget {
+		t.Fatalf("unexpected request %+v", client.req)
+	}
+}
+
+func TestContext_AppExUsesEnv(t *testing.T) {
+	client := &appServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var opts polycode.TaskOptions
+	if err := ctx.AppEx("env-2", "billing").Send(opts, "Invoice", 10); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if client.req.EnvId != "env-2" || client.req.AppName != "billing" || !client.req.FireAndForget {
+		t.Fatalf("unexpected request %+v", client.req)
+	}
+}
+
+func TestContext_AppRequestReplyWrapsError(t *testing.T) {
+	unavailable := errors.New("sidecar unavailable")
+	client := &appServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		err:               unavailable,
+	}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var opts polycode.TaskOptions
+	res := ctx.App("billing").RequestReply(opts, "Invoice", 10)
+	if !res.IsError() {
+		t.Fatalf("expected an error response")
+	}
+	if _, ok := res.(Response); !ok {
+		t.Fatalf("expected a runtime Response, got %T", res)
+	}
+
+	_, err := res.GetAny()
+	if !errors.Is(err, ErrTaskExecError) {
+		t.Fatalf("expected a task exec error, got %v", err)
+	}
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("expected the sidecar error to be kept in the chain, got %v", err)
+	}
+}
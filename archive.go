@@ -0,0 +1,354 @@
+package runtime
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	ArchiveZip   ArchiveFormat = "zip"
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+)
+
+// DefaultMaxExtractSize bounds the total decompressed size Folder.Extract writes
+const DefaultMaxExtractSize int64 = 1024 * 1024 * 1024
+
+type ArchiveFormat string
+
+// ExtractOptions controls Folder.ExtractWithOptions
+type ExtractOptions struct {
+	// Format of the archive, empty detects it from the name of the source file
+	Format ArchiveFormat
+	// MaxSize bounds the total decompressed size of the extracted files, 0 uses DefaultMaxExtractSize
+	MaxSize int64
+}
+
+// Archive writes every file below this folder into dest as a zip or tar.gz archive. The content is
+// streamed from the file store into the archive, nothing is buffered on local disk.
+func (f Folder) Archive(format ArchiveFormat, dest polycode.File) error {
+	target, ok := dest.(File)
+	if !ok {
+		return errors.New("client: archive destination is not a file store file")
+	}
+
+	if format != ArchiveZip && format != ArchiveTarGz {
+		return ErrUnsupportedArchiveFormat.With(string(format))
+	}
+
+	w, err := target.Create(StreamOptions{})
+	if err != nil {
+		return err
+	}
+
+	if format == ArchiveZip {
+		err = f.archiveZip(w, target.Path())
+	} else {
+		err = f.archiveTarGz(w, target.Path())
+	}
+
+	if err != nil {
+		// abort the upload so a partial archive is never saved
+		abortStream(w, err)
+		fmt.Printf("failed to archive folder: %s\n", err.Error())
+		return err
+	}
+
+	return w.Close()
+}
+
+func (f Folder) archiveZip(w io.Writer, destPath string) error {
+	zw := zip.NewWriter(w)
+	err := f.Walk(func(name string, entry FolderEntry) error {
+		if !entry.IsFolder && entry.File.Path() == destPath {
+			return nil
+		}
+
+		if entry.IsFolder {
+			_, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     name + "/",
+				Modified: entry.LastModified,
+			})
+			return err
+		}
+
+		entryWriter, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: entry.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+
+		return copyFileTo(entry.File, entryWriter)
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func (f Folder) archiveTarGz(w io.Writer, destPath string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	err := f.Walk(func(name string, entry FolderEntry) error {
+		if !entry.IsFolder && entry.File.Path() == destPath {
+			return nil
+		}
+
+		if entry.IsFolder {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     0o755,
+				ModTime:  entry.LastModified,
+			})
+		}
+
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     entry.Size,
+			ModTime:  entry.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+
+		return copyFileTo(entry.File, tw)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func copyFileTo(file polycode.File, w io.Writer) error {
+	r, err := file.(File).Open(StreamOptions{})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// Extract unpacks the zip or tar.gz archive src into this folder, see ExtractWithOptions.
+// A tar.gz archive is streamed, but a zip archive is read from its central directory at the end
+// of the file, so it is first downloaded in full to a temp file in os.TempDir and removed after.
+// Local disk must have room for the whole zip archive.
+func (f Folder) Extract(src polycode.File) error {
+	return f.ExtractWithOptions(src, ExtractOptions{})
+}
+
+// ExtractWithOptions unpacks the archive src into this folder. Entries whose path would land outside
+// the folder are rejected, only regular files are extracted, and extraction stops once the files
+// written exceed the size limit. Files extracted before an error are left in place.
+func (f Folder) ExtractWithOptions(src polycode.File, options ExtractOptions) error {
+	source, ok := src.(File)
+	if !ok {
+		return errors.New("client: archive source is not a file store file")
+	}
+
+	format := options.Format
+	if format == "" {
+		switch name := strings.ToLower(source.Name()); {
+		case strings.HasSuffix(name, ".zip"):
+			format = ArchiveZip
+		case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+			format = ArchiveTarGz
+		}
+	}
+
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxExtractSize
+	}
+
+	x := &extractor{
+		folder:    f,
+		remaining: maxSize,
+		maxSize:   maxSize,
+	}
+
+	var err error
+	switch format {
+	case ArchiveZip:
+		err = x.extractZip(source)
+	case ArchiveTarGz:
+		err = x.extractTarGz(source)
+	default:
+		return ErrUnsupportedArchiveFormat.With(string(format))
+	}
+
+	if err != nil {
+		fmt.Printf("failed to extract archive: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+type extractor struct {
+	folder    Folder
+	remaining int64
+	maxSize   int64
+}
+
+func (x *extractor) extractZip(source File) error {
+	// zip keeps its directory at the end, so the archive is spooled to a local temp file for random access
+	tmp, err := os.CreateTemp("", "polycode-extract-*.zip")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	r, err := source.Open(StreamOptions{MaxSize: x.maxSize})
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(tmp, r)
+	_ = r.Close()
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range zr.File {
+		name, err := safeArchivePath(entry.Name)
+		if err != nil {
+			return err
+		}
+
+		if name == "" || !entry.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := entry.Open()
+		if err != nil {
+			return err
+		}
+
+		err = x.write(name, content)
+		_ = content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) extractTarGz(source File) error {
+	r, err := source.Open(StreamOptions{})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := safeArchivePath(header.Name)
+		if err != nil {
+			return err
+		}
+
+		// links and devices are skipped, a symlink could otherwise point outside the folder
+		if name == "" || header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err = x.write(name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// write streams content into the file at name, counting it against the remaining size budget
+func (x *extractor) write(name string, content io.Reader) error {
+	folder := x.folder
+	dir, base := path.Split(name)
+	for _, part := range strings.Split(strings.TrimSuffix(dir, "/"), "/") {
+		if part != "" {
+			folder = folder.Folder(part).(Folder)
+		}
+	}
+
+	w, err := folder.File(base).(File).Create(StreamOptions{})
+	if err != nil {
+		return err
+	}
+
+	// read one byte past the budget to tell an exact fit from an overflow
+	n, err := io.Copy(w, io.LimitReader(content, x.remaining+1))
+	if err == nil && n > x.remaining {
+		err = ErrArchiveTooLarge.With(x.maxSize)
+	}
+
+	if err != nil {
+		abortStream(w, err)
+		return err
+	}
+
+	x.remaining -= n
+	return w.Close()
+}
+
+// safeArchivePath rejects entry names that are absolute or climb out of the extraction folder
+// and returns the cleaned relative path, empty for an entry naming the archive root such as "./"
+func safeArchivePath(name string) (string, error) {
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if normalized == "" || strings.HasPrefix(normalized, "/") || strings.Contains(strings.SplitN(normalized, "/", 2)[0], ":") {
+		return "", ErrUnsafeArchivePath.With(name)
+	}
+
+	for _, part := range strings.Split(normalized, "/") {
+		if part == ".." {
+			return "", ErrUnsafeArchivePath.With(name)
+		}
+	}
+
+	clean := path.Clean(normalized)
+	if clean == "." {
+		return "", nil
+	}
+
+	return clean, nil
+}
@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFolder_ArchiveThenExtract(t *testing.T) {
+	for _, format := range []ArchiveFormat{ArchiveZip, ArchiveTarGz} {
+		client := newFileServiceClient(map[string]string{
+			"files/reports/a.txt":     "alpha",
+			"files/reports/q3/b.csv":  "1,2,3",
+			"files/reports/q3/c.json": "{}",
+		})
+		root := Folder{client: client, sessionId: "s1", name: "files"}
+
+		archive := root.File("bundle." + string(format))
+		if err := root.Folder("reports").(Folder).Archive(format, archive); err != nil {
+			t.Fatalf("%s: archive failed: %v", format, err)
+		}
+
+		if err := root.Folder("restored").(Folder).Extract(archive); err != nil {
+			t.Fatalf("%s: extract failed: %v", format, err)
+		}
+
+		for key, want := range map[string]string{
+			"files/restored/a.txt":     "alpha",
+			"files/restored/q3/b.csv":  "1,2,3",
+			"files/restored/q3/c.json": "{}",
+		} {
+			if got := client.content(key); got != want {
+				t.Fatalf("%s: expected %s to contain %q, got %q", format, key, want, got)
+			}
+		}
+	}
+}
+
+func TestFolder_ExtractRejectsUnsafeAndOversizedArchives(t *testing.T) {
+	zipOf := func(entries map[string]string) string {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range entries {
+			w, _ := zw.Create(name)
+			_, _ = w.Write([]byte(content))
+		}
+		_ = zw.Close()
+		return buf.String()
+	}
+
+	client := newFileServiceClient(map[string]string{
+		"files/slip.zip": zipOf(map[string]string{"../../etc/passwd": "root"}),
+		"files/bomb.zip": zipOf(map[string]string{"big.txt": strings.Repeat("x", 1024)}),
+	})
+	root := Folder{client: client, sessionId: "s1", name: "files"}
+	target := root.Folder("out").(Folder)
+
+	err := target.Extract(root.File("slip.zip"))
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected zip-slip entry to be rejected, got %v", err)
+	}
+
+	err = target.ExtractWithOptions(root.File("bomb.zip"), ExtractOptions{MaxSize: 512})
+	if err == nil || !strings.Contains(err.Error(), "size limit") {
+		t.Fatalf("expected oversized archive to be rejected, got %v", err)
+	}
+
+	for _, key := range client.keys() {
+		if strings.HasPrefix(key, "files/out/") || strings.Contains(key, "passwd") {
+			t.Fatalf("unexpected extracted file %s", key)
+		}
+	}
+}
+
+func TestSafeArchivePath(t *testing.T) {
+	for name, want := range map[string]string{
+		"a/b.txt":   "a/b.txt",
+		"./a//b":    "a/b",
+		"./":        "",
+		"/etc/x":    "!",
+		"a/../../x": "!",
+		"..\\x":     "!",
+		"C:/x":      "!",
+	} {
+		got, err := safeArchivePath(name)
+		if want == "!" {
+			if err == nil {
+				t.Fatalf("expected %q to be rejected", name)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Fatalf("safeArchivePath(%q) = %q, %v", name, got, err)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package runtime
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/cloudimpl/polycode-sdk-go"
 	"github.com/cloudimpl/polycode-sdk-go/errors"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -35,10 +39,12 @@ type ServiceDescription struct {
 }
 
 type MethodDescription struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	IsWorkflow  bool        `json:"isWorkflow"`
-	Input       interface{} `json:"input"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	IsWorkflow  bool                 `json:"isWorkflow"`
+	Input       interface{}          `json:"input"`
+	Versions    []VersionDescription `json:"versions"`
+	Timeout     int64                `json:"timeout"`
 }
 
 type StartAppRequest struct {
@@ -49,6 +55,31 @@ type StartAppRequest struct {
 	Routes     []RouteData          `json:"routes"`
 }
 
+// VersionMarkersRequest asks for the version markers recorded by executions of the app that have not
+// reached a terminal state
+type VersionMarkersRequest struct {
+	AppName string `json:"appName"`
+}
+
+// VersionMarker is a version of a change point recorded by at least one unfinished execution of the method
+type VersionMarker struct {
+	Method     string `json:"method"`
+	ChangeId   string `json:"changeId"`
+	Version    int    `json:"version"`
+	Executions int    `json:"executions"`
+}
+
+type VersionMarkersResponse struct {
+	Markers []VersionMarker `json:"markers"`
+}
+
+// StopAppRequest tells the sidecar the app is draining and must not receive new invocations
+type StopAppRequest struct {
+	AppName string `json:"appName"`
+	AppPort uint   `json:"appPort"`
+	Reason  string `json:"reason"`
+}
+
 type ExecServiceRequest struct {
 	EnvId         string               `json:"envId"`
 	Service       string               `json:"service"`
@@ -80,6 +111,7 @@ type ExecAppExtendedRequest struct {
 
 type ExecServiceResponse struct {
 	IsAsync bool         `json:"isAsync"`
+	TaskId  string       `json:"taskId"`
 	Output  any          `json:"output"`
 	IsError bool         `json:"isError"`
 	Error   errors.Error `json:"error"`
@@ -87,6 +119,7 @@ type ExecServiceResponse struct {
 
 type ExecAppResponse struct {
 	IsAsync bool         `json:"isAsync"`
+	TaskId  string       `json:"taskId"`
 	Output  any          `json:"output"`
 	IsError bool         `json:"isError"`
 	Error   errors.Error `json:"error"`
@@ -113,20 +146,46 @@ type ExecApiResponse struct {
 	Error    errors.Error         `json:"error"`
 }
 
+// ExecServiceBatchRequest starts a set of service calls together, BatchId is empty until the sidecar assigns one
+type ExecServiceBatchRequest struct {
+	BatchId  string               `json:"batchId"`
+	Requests []ExecServiceRequest `json:"requests"`
+	WaitFor  BatchWaitMode        `json:"waitFor"`
+}
+
+// ExecServiceBatchResponse holds one response per request in request order, pending calls are marked IsAsync
+type ExecServiceBatchResponse struct {
+	IsAsync        bool                  `json:"isAsync"`
+	BatchId        string                `json:"batchId"`
+	Responses      []ExecServiceResponse `json:"responses"`
+	CompletedIndex int                   `json:"completedIndex"`
+}
+
+// ExecFuncRequest looks up the next memo result, Key is empty for positional memos
 type ExecFuncRequest struct {
-	Input any `json:"input"`
+	Key   string `json:"key"`
+	Input any    `json:"input"`
 }
 
+// ExecFuncResult records a memo result. With IsRetry set the result is a failed attempt that is not final,
+// and the sidecar suspends the task for RetryAfter milliseconds before it is re-executed.
 type ExecFuncResult struct {
-	Input   any          `json:"input"`
-	Output  any          `json:"output"`
-	IsError bool         `json:"isError"`
-	Error   errors.Error `json:"error"`
+	Key        string       `json:"key"`
+	Input      any          `json:"input"`
+	Output     any          `json:"output"`
+	IsError    bool         `json:"isError"`
+	Error      errors.Error `json:"error"`
+	IsRetry    bool         `json:"isRetry"`
+	Attempt    int          `json:"attempt"`
+	RetryAfter int64        `json:"retryAfter"`
 }
 
 type ExecFuncResponse struct {
 	IsAsync     bool         `json:"isAsync"`
 	IsCompleted bool         `json:"isCompleted"`
+	IsReplaying bool         `json:"isReplaying"`
+	Key         string       `json:"key"`
+	Attempt     int          `json:"attempt"`
 	Output      any          `json:"output"`
 	IsError     bool         `json:"isError"`
 	Error       errors.Error `json:"error"`
@@ -157,6 +216,8 @@ type QueryRequest struct {
 // GetFileRequest represents the JSON structure for get file operations
 type GetFileRequest struct {
 	Key string `json:"key"`
+	// VersionId reads an earlier version of a file in a versioned folder, empty reads the latest
+	VersionId string `json:"versionId,omitempty"`
 }
 
 // GetFileResponse represents the JSON structure for get file response
@@ -164,8 +225,33 @@ type GetFileResponse struct {
 	Content string `json:"content"`
 }
 
+// GetDownloadLinkRequest asks for a presigned link to read a file
+type GetDownloadLinkRequest struct {
+	Key string `json:"key"`
+	// Expiry of the link in milliseconds, 0 uses the sidecar default
+	Expiry             int64  `json:"expiry,omitempty"`
+	Method             string `json:"method,omitempty"`
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+	ContentType        string `json:"contentType,omitempty"`
+}
+
+// GetUploadLinkRequest asks for a presigned link to write a file
+type GetUploadLinkRequest struct {
+	Key      string `json:"key"`
+	TempFile bool   `json:"tempFile"`
+	// Expiry of the link in milliseconds, 0 uses the sidecar default
+	Expiry      int64  `json:"expiry,omitempty"`
+	Method      string `json:"method,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	MaxSize     int64  `json:"maxSize,omitempty"`
+}
+
 type GetLinkResponse struct {
-	Link string `json:"link"`
+	Link      string `json:"link"`
+	Method    string `json:"method,omitempty"`
+	ExpiresAt int64  `json:"expiresAt,omitempty"`
+	// Headers the caller must send with the request for the signature to match
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 // PutFileRequest represents the JSON structure for put file operations
@@ -173,11 +259,81 @@ type PutFileRequest struct {
 	Key      string `json:"key"`
 	TempFile bool   `json:"tempFile"`
 	Content  string `json:"content"`
-	FilePath string `json:"filePath"`
+	// TTL of a temp file in milliseconds, 0 uses the sidecar default
+	TTL int64 `json:"ttl,omitempty"`
+	// FilePath is the name of the local file a streamed upload was read from
+	FilePath     string            `json:"filePath"`
+	ContentType  string            `json:"contentType,omitempty"`
+	CacheControl string            `json:"cacheControl,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	// ChecksumSha256 is the hex encoded SHA-256 of the content, the sidecar rejects the write on a mismatch.
+	// Streamed uploads send it in the ChecksumTrailer instead, as it is only known once the body is read.
+	ChecksumSha256 string `json:"checksumSha256,omitempty"`
+}
+
+// StatFileRequest represents the JSON structure for stat file operations
+type StatFileRequest struct {
+	Key string `json:"key"`
+}
+
+// StatFileResponse describes a stored file without its content
+type StatFileResponse struct {
+	Exists         bool              `json:"exists"`
+	Key            string            `json:"key"`
+	Size           int64             `json:"size"`
+	ETag           string            `json:"etag"`
+	ChecksumSha256 string            `json:"checksumSha256"`
+	ContentType    string            `json:"contentType"`
+	CacheControl   string            `json:"cacheControl"`
+	Metadata       map[string]string `json:"metadata"`
+	LastModified   time.Time         `json:"lastModified"`
 }
 
 type DeleteFileRequest struct {
 	Key string `json:"key"`
+	// Soft keeps the file restorable until the restore window of its folder ends
+	Soft bool `json:"soft,omitempty"`
+}
+
+// SetFolderVersioningRequest turns versioning of the files below a folder on or off
+type SetFolderVersioningRequest struct {
+	Folder  string `json:"folder"`
+	Enabled bool   `json:"enabled"`
+	// MaxVersions kept per file, 0 keeps every version
+	MaxVersions int32 `json:"maxVersions,omitempty"`
+	// RestoreWindow in milliseconds a soft deleted file stays restorable, 0 uses the sidecar default
+	RestoreWindow int64 `json:"restoreWindow,omitempty"`
+}
+
+type ListFileVersionsRequest struct {
+	Key string `json:"key"`
+}
+
+// FileVersion is one stored version of a file, newest first in ListFileVersionsResponse
+type FileVersion struct {
+	VersionId    string    `json:"versionId"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+	IsLatest     bool      `json:"isLatest"`
+	// IsDeleted marks the version recording a soft delete, PurgeAt is when the file stops being restorable
+	IsDeleted bool      `json:"isDeleted"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+type ListFileVersionsResponse struct {
+	Versions []FileVersion `json:"versions"`
+}
+
+// RestoreFileVersionRequest makes a copy of an earlier version the latest version of the file
+type RestoreFileVersionRequest struct {
+	Key       string `json:"key"`
+	VersionId string `json:"versionId"`
+}
+
+// UndeleteFileRequest restores a soft deleted file within its restore window
+type UndeleteFileRequest struct {
+	Key string `json:"key"`
 }
 
 type RenameFileRequest struct {
@@ -186,8 +342,82 @@ type RenameFileRequest struct {
 	TempFile bool   `json:"tempFile"`
 }
 
+// CopyFileRequest copies a file inside the file store without transferring its content through the app
+type CopyFileRequest struct {
+	SourceKey string `json:"sourceKey"`
+	DestKey   string `json:"destKey"`
+	TempFile  bool   `json:"tempFile"`
+}
+
+// CreateMultipartUploadRequest starts an upload whose content is sent as separately uploaded parts
+type CreateMultipartUploadRequest struct {
+	Key          string            `json:"key"`
+	TempFile     bool              `json:"tempFile"`
+	TTL          int64             `json:"ttl,omitempty"`
+	ContentType  string            `json:"contentType,omitempty"`
+	CacheControl string            `json:"cacheControl,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+type CreateMultipartUploadResponse struct {
+	UploadId string `json:"uploadId"`
+}
+
+// GetPartUploadLinkRequest asks for a presigned link to upload one part of a multipart upload
+type GetPartUploadLinkRequest struct {
+	Key        string `json:"key"`
+	UploadId   string `json:"uploadId"`
+	PartNumber int32  `json:"partNumber"`
+	// Expiry of the link in milliseconds, 0 uses the sidecar default
+	Expiry int64 `json:"expiry,omitempty"`
+}
+
+// PutFilePartRequest is sent with the raw content of one part of a multipart upload
+type PutFilePartRequest struct {
+	Key        string `json:"key"`
+	UploadId   string `json:"uploadId"`
+	PartNumber int32  `json:"partNumber"`
+}
+
+// UploadedPart identifies a part accepted by the file store
+type UploadedPart struct {
+	PartNumber int32  `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+type ListFilePartsRequest struct {
+	Key      string `json:"key"`
+	UploadId string `json:"uploadId"`
+}
+
+type ListFilePartsResponse struct {
+	Parts []UploadedPart `json:"parts"`
+}
+
+// CompleteMultipartUploadRequest assembles the given parts, in part number order, into the file
+type CompleteMultipartUploadRequest struct {
+	Key      string         `json:"key"`
+	UploadId string         `json:"uploadId"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+type AbortMultipartUploadRequest struct {
+	Key      string `json:"key"`
+	UploadId string `json:"uploadId"`
+}
+
+// PromoteFileRequest moves a temp file into the permanent file store in one step, dropping its TTL
+type PromoteFileRequest struct {
+	Key     string `json:"key"`
+	DestKey string `json:"destKey"`
+}
+
 type CreateFolderRequest struct {
 	Folder string `json:"folder"`
+	// parent is the path of the folder the new folder is created in. The sidecar resolves it from the
+	// session, so it is never sent; only in-process backends such as LocalFileStore use it.
+	parent string
 }
 
 // ListFilePageRequest carries the usual params plus the ContinuationToken from the previous page.
@@ -224,17 +454,59 @@ type RealtimeEventEmitRequest struct {
 	Input   any    `json:"input"`
 }
 
+// SignalWaitRequest waits for a named signal, Timeout is in milliseconds and 0 waits forever
 type SignalWaitRequest struct {
 	SignalName string `json:"signalName"`
+	Timeout    int64  `json:"timeout"`
 }
 
 type SignalWaitResponse struct {
+	IsAsync   bool         `json:"isAsync"`
+	IsTimeout bool         `json:"isTimeout"`
+	Output    any          `json:"output"`
+	IsError   bool         `json:"isError"`
+	Error     errors.Error `json:"error"`
+}
+
+// SignalSelectRequest waits for the first of several signals, Timeout is in milliseconds and 0 waits forever
+type SignalSelectRequest struct {
+	SignalNames []string `json:"signalNames"`
+	Timeout     int64    `json:"timeout"`
+}
+
+type SignalSelectResponse struct {
+	IsAsync    bool         `json:"isAsync"`
+	IsTimeout  bool         `json:"isTimeout"`
+	SignalName string       `json:"signalName"`
+	Output     any          `json:"output"`
+	IsError    bool         `json:"isError"`
+	Error      errors.Error `json:"error"`
+}
+
+type TaskAwaitRequest struct {
+	TaskId string `json:"taskId"`
+}
+
+type TaskAwaitResponse struct {
 	IsAsync bool         `json:"isAsync"`
 	Output  any          `json:"output"`
 	IsError bool         `json:"isError"`
 	Error   errors.Error `json:"error"`
 }
 
+type TaskStatusRequest struct {
+	TaskId string `json:"taskId"`
+}
+
+type TaskStatusResponse struct {
+	Status TaskStatus `json:"status"`
+}
+
+type TaskCancelRequest struct {
+	TaskId string `json:"taskId"`
+	Reason string `json:"reason"`
+}
+
 type GetMetaDataRequest struct {
 	Group string `json:"group"`
 	Type  string `json:"type"`
@@ -273,14 +545,20 @@ func NewServiceClient(baseURL string) ServiceClient {
 		httpClient: &http.Client{
 			Timeout: time.Second * 30, // Set a reasonable timeout for HTTP requests
 		},
-		baseURL: baseURL,
+		// file streams can run far longer than a regular call, they are bound by the invocation context instead
+		streamClient: &http.Client{},
+		baseURL:      baseURL,
 	}
 }
 
 type ServiceClient interface {
 	StartApp(req StartAppRequest) error
+	StopApp(req StopAppRequest) error
+	ListVersionMarkers(req VersionMarkersRequest) (VersionMarkersResponse, error)
+	Ping(ctx context.Context) error
 
 	ExecService(sessionId string, req ExecServiceRequest) (ExecServiceResponse, error)
+	ExecServiceBatch(sessionId string, req ExecServiceBatchRequest) (ExecServiceBatchResponse, error)
 	ExecApp(sessionId string, req ExecAppRequest) (ExecAppResponse, error)
 	ExecApi(sessionId string, req ExecApiRequest) (ExecApiResponse, error)
 	ExecFunc(sessionId string, req ExecFuncRequest) (ExecFuncResponse, error)
@@ -291,18 +569,38 @@ type ServiceClient interface {
 	PutItem(sessionId string, req PutRequest) error
 
 	GetFile(sessionId string, req GetFileRequest) (GetFileResponse, error)
-	GetFileDownloadLink(sessionId string, req GetFileRequest) (GetLinkResponse, error)
+	GetFileStream(sessionId string, req GetFileRequest) (io.ReadCloser, error)
+	GetFileDownloadLink(sessionId string, req GetDownloadLinkRequest) (GetLinkResponse, error)
 	PutFile(sessionId string, req PutFileRequest) error
-	GetFileUploadLink(sessionId string, req GetFileRequest) (GetLinkResponse, error)
+	PutFileStream(sessionId string, req PutFileRequest, body io.Reader) error
+	GetFileUploadLink(sessionId string, req GetUploadLinkRequest) (GetLinkResponse, error)
 	DeleteFile(sessionId string, req DeleteFileRequest) error
 	RenameFile(sessionId string, req RenameFileRequest) error
+	CopyFile(sessionId string, req CopyFileRequest) error
+	StatFile(sessionId string, req StatFileRequest) (StatFileResponse, error)
+	PromoteFile(sessionId string, req PromoteFileRequest) error
+	SetFolderVersioning(sessionId string, req SetFolderVersioningRequest) error
+	ListFileVersions(sessionId string, req ListFileVersionsRequest) (ListFileVersionsResponse, error)
+	RestoreFileVersion(sessionId string, req RestoreFileVersionRequest) error
+	UndeleteFile(sessionId string, req UndeleteFileRequest) error
+	CreateMultipartUpload(sessionId string, req CreateMultipartUploadRequest) (CreateMultipartUploadResponse, error)
+	GetPartUploadLink(sessionId string, req GetPartUploadLinkRequest) (GetLinkResponse, error)
+	PutFilePart(sessionId string, req PutFilePartRequest, body io.Reader) (UploadedPart, error)
+	ListFileParts(sessionId string, req ListFilePartsRequest) (ListFilePartsResponse, error)
+	CompleteMultipartUpload(sessionId string, req CompleteMultipartUploadRequest) error
+	AbortMultipartUpload(sessionId string, req AbortMultipartUploadRequest) error
 	ListFile(sessionId string, req ListFilePageRequest) (ListFilePageResponse, error)
 	CreateFolder(sessionId string, req CreateFolderRequest) error
 
 	EmitSignal(sessionId string, req SignalEmitRequest) error
 	WaitForSignal(sessionId string, req SignalWaitRequest) (SignalWaitResponse, error)
+	SelectSignal(sessionId string, req SignalSelectRequest) (SignalSelectResponse, error)
 	EmitRealtimeEvent(sessionId string, req RealtimeEventEmitRequest) error
 
+	AwaitTask(sessionId string, req TaskAwaitRequest) (TaskAwaitResponse, error)
+	GetTaskStatus(sessionId string, req TaskStatusRequest) (TaskStatusResponse, error)
+	CancelTask(sessionId string, req TaskCancelRequest) error
+
 	AcquireLock(sessionId string, req AcquireLockRequest) error
 	ReleaseLock(sessionId string, req ReleaseLockRequest) error
 
@@ -314,8 +612,9 @@ type ServiceClient interface {
 
 // ServiceClientImpl is a reusable client for calling the service API
 type ServiceClientImpl struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient   *http.Client
+	streamClient *http.Client
+	baseURL      string
 }
 
 // StartApp starts the app
@@ -323,6 +622,38 @@ func (sc *ServiceClientImpl) StartApp(req StartAppRequest) error {
 	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, "", "v1/system/app/start", req)
 }
 
+// StopApp notifies the sidecar that the app is stopping
+func (sc *ServiceClientImpl) StopApp(req StopAppRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, "", "v1/system/app/stop", req)
+}
+
+// ListVersionMarkers returns the version markers of the app's unfinished executions
+func (sc *ServiceClientImpl) ListVersionMarkers(req VersionMarkersRequest) (VersionMarkersResponse, error) {
+	var res VersionMarkersResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, "", "v1/system/app/versions", req, &res)
+	return res, err
+}
+
+// Ping checks that the sidecar is reachable, giving up when ctx ends
+func (sc *ServiceClientImpl) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", sc.baseURL, "v1/health"), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := sc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http error, status: %v", resp.Status)
+	}
+
+	return nil
+}
+
 // ExecService executes a service with the given request
 func (sc *ServiceClientImpl) ExecService(sessionId string, req ExecServiceRequest) (ExecServiceResponse, error) {
 	var res ExecServiceResponse
@@ -338,6 +669,20 @@ func (sc *ServiceClientImpl) ExecService(sessionId string, req ExecServiceReques
 	return res, nil
 }
 
+func (sc *ServiceClientImpl) ExecServiceBatch(sessionId string, req ExecServiceBatchRequest) (ExecServiceBatchResponse, error) {
+	var res ExecServiceBatchResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/service/exec-batch", req, &res)
+	if err != nil {
+		return ExecServiceBatchResponse{}, err
+	}
+
+	if res.IsAsync {
+		panic(ErrTaskStopped)
+	}
+
+	return res, nil
+}
+
 func (sc *ServiceClientImpl) ExecApp(sessionId string, req ExecAppRequest) (ExecAppResponse, error) {
 	var res ExecAppResponse
 	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/app/exec", req, &res)
@@ -420,7 +765,12 @@ func (sc *ServiceClientImpl) GetFile(sessionId string, req GetFileRequest) (GetF
 	return res, err
 }
 
-func (sc *ServiceClientImpl) GetFileDownloadLink(sessionId string, req GetFileRequest) (GetLinkResponse, error) {
+// GetFileStream opens the raw content of a file, the caller must close the returned reader
+func (sc *ServiceClientImpl) GetFileStream(sessionId string, req GetFileRequest) (io.ReadCloser, error) {
+	return executeStreamDownload(sc.streamClient, sc.baseURL, sessionId, "v1/context/file/get-stream", req)
+}
+
+func (sc *ServiceClientImpl) GetFileDownloadLink(sessionId string, req GetDownloadLinkRequest) (GetLinkResponse, error) {
 	var res GetLinkResponse
 	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/get-download-link", req, &res)
 	return res, err
@@ -431,6 +781,11 @@ func (sc *ServiceClientImpl) PutFile(sessionId string, req PutFileRequest) error
 	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/put", req)
 }
 
+// PutFileStream streams the body to the file store as a chunked raw request
+func (sc *ServiceClientImpl) PutFileStream(sessionId string, req PutFileRequest, body io.Reader) error {
+	return executeStreamUpload(sc.streamClient, sc.baseURL, sessionId, "v1/context/file/put-stream", req, body)
+}
+
 func (sc *ServiceClientImpl) GetFileUploadLink(sessionId string, req GetUploadLinkRequest) (GetLinkResponse, error) {
 	var res GetLinkResponse
 	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/get-upload-link", req, &res)
@@ -445,6 +800,77 @@ func (sc *ServiceClientImpl) RenameFile(sessionId string, req RenameFileRequest)
 	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/rename", req)
 }
 
+// CopyFile copies a file on the file store side
+func (sc *ServiceClientImpl) CopyFile(sessionId string, req CopyFileRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/copy", req)
+}
+
+// StatFile gets the size, checksum and metadata of a file
+func (sc *ServiceClientImpl) StatFile(sessionId string, req StatFileRequest) (StatFileResponse, error) {
+	var res StatFileResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/stat", req, &res)
+	return res, err
+}
+
+// PromoteFile makes a temp file permanent
+func (sc *ServiceClientImpl) PromoteFile(sessionId string, req PromoteFileRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/promote", req)
+}
+
+func (sc *ServiceClientImpl) SetFolderVersioning(sessionId string, req SetFolderVersioningRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/versioning", req)
+}
+
+// ListFileVersions returns the stored versions of a file in a versioned folder
+func (sc *ServiceClientImpl) ListFileVersions(sessionId string, req ListFileVersionsRequest) (ListFileVersionsResponse, error) {
+	var res ListFileVersionsResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/versions", req, &res)
+	return res, err
+}
+
+func (sc *ServiceClientImpl) RestoreFileVersion(sessionId string, req RestoreFileVersionRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/restore-version", req)
+}
+
+func (sc *ServiceClientImpl) UndeleteFile(sessionId string, req UndeleteFileRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/undelete", req)
+}
+
+// CreateMultipartUpload starts a multipart upload and returns its id
+func (sc *ServiceClientImpl) CreateMultipartUpload(sessionId string, req CreateMultipartUploadRequest) (CreateMultipartUploadResponse, error) {
+	var res CreateMultipartUploadResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/multipart/create", req, &res)
+	return res, err
+}
+
+func (sc *ServiceClientImpl) GetPartUploadLink(sessionId string, req GetPartUploadLinkRequest) (GetLinkResponse, error) {
+	var res GetLinkResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/multipart/part-link", req, &res)
+	return res, err
+}
+
+// PutFilePart streams one part of a multipart upload to the file store
+func (sc *ServiceClientImpl) PutFilePart(sessionId string, req PutFilePartRequest, body io.Reader) (UploadedPart, error) {
+	var res UploadedPart
+	err := executeStreamUploadWithResponse(sc.streamClient, sc.baseURL, sessionId, "v1/context/file/multipart/put-part", req, body, &res)
+	return res, err
+}
+
+// ListFileParts returns the parts already accepted for a multipart upload
+func (sc *ServiceClientImpl) ListFileParts(sessionId string, req ListFilePartsRequest) (ListFilePartsResponse, error) {
+	var res ListFilePartsResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/multipart/list-parts", req, &res)
+	return res, err
+}
+
+func (sc *ServiceClientImpl) CompleteMultipartUpload(sessionId string, req CompleteMultipartUploadRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/multipart/complete", req)
+}
+
+func (sc *ServiceClientImpl) AbortMultipartUpload(sessionId string, req AbortMultipartUploadRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/multipart/abort", req)
+}
+
 func (sc *ServiceClientImpl) ListFile(sessionId string, req ListFilePageRequest) (ListFilePageResponse, error) {
 	var res ListFilePageResponse
 	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/file/list", req, &res)
@@ -462,13 +888,59 @@ func (sc *ServiceClientImpl) EmitSignal(sessionId string, req SignalEmitRequest)
 func (sc *ServiceClientImpl) WaitForSignal(sessionId string, req SignalWaitRequest) (SignalWaitResponse, error) {
 	res := SignalWaitResponse{}
 	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/signal/await", req, &res)
-	return res, err
+	if err != nil {
+		return SignalWaitResponse{}, err
+	}
+
+	if res.IsAsync {
+		panic(ErrTaskStopped)
+	}
+
+	return res, nil
+}
+
+func (sc *ServiceClientImpl) SelectSignal(sessionId string, req SignalSelectRequest) (SignalSelectResponse, error) {
+	res := SignalSelectResponse{}
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/signal/select", req, &res)
+	if err != nil {
+		return SignalSelectResponse{}, err
+	}
+
+	if res.IsAsync {
+		panic(ErrTaskStopped)
+	}
+
+	return res, nil
 }
 
 func (sc *ServiceClientImpl) EmitRealtimeEvent(sessionId string, req RealtimeEventEmitRequest) error {
 	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/realtime/event/emit", req)
 }
 
+func (sc *ServiceClientImpl) AwaitTask(sessionId string, req TaskAwaitRequest) (TaskAwaitResponse, error) {
+	var res TaskAwaitResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/task/await", req, &res)
+	if err != nil {
+		return TaskAwaitResponse{}, err
+	}
+
+	if res.IsAsync {
+		panic(ErrTaskStopped)
+	}
+
+	return res, nil
+}
+
+func (sc *ServiceClientImpl) GetTaskStatus(sessionId string, req TaskStatusRequest) (TaskStatusResponse, error) {
+	var res TaskStatusResponse
+	err := executeApiWithResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/task/status", req, &res)
+	return res, err
+}
+
+func (sc *ServiceClientImpl) CancelTask(sessionId string, req TaskCancelRequest) error {
+	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/task/cancel", req)
+}
+
 func (sc *ServiceClientImpl) AcquireLock(sessionId string, req AcquireLockRequest) error {
 	return executeApiWithoutResponse(sc.httpClient, sc.baseURL, sessionId, "v1/context/lock/acquire", req)
 }
@@ -501,7 +973,8 @@ func executeApiWithoutResponse(httpClient *http.Client, baseUrl string, sessionI
 		return err
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), bytes.NewBuffer(reqBody))
+	// sidecar calls are bound to the invocation of the session so they abort with its deadline
+	httpReq, err := http.NewRequestWithContext(Invocations.Context(sessionId), http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
@@ -511,6 +984,7 @@ func executeApiWithoutResponse(httpClient *http.Client, baseUrl string, sessionI
 
 	resp, err := httpClient.Do(httpReq)
 	if err != nil {
+		captureDeadlineStack(httpReq.Context())
 		return err
 	}
 	defer resp.Body.Close()
@@ -530,7 +1004,8 @@ func executeApiWithResponse[T any](httpClient *http.Client, baseUrl string, sess
 		return err
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), bytes.NewBuffer(reqBody))
+	// sidecar calls are bound to the invocation of the session so they abort with its deadline
+	httpReq, err := http.NewRequestWithContext(Invocations.Context(sessionId), http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
@@ -540,6 +1015,7 @@ func executeApiWithResponse[T any](httpClient *http.Client, baseUrl string, sess
 
 	resp, err := httpClient.Do(httpReq)
 	if err != nil {
+		captureDeadlineStack(httpReq.Context())
 		return err
 	}
 	defer resp.Body.Close()
@@ -563,3 +1039,118 @@ func executeApiWithResponse[T any](httpClient *http.Client, baseUrl string, sess
 		return errorEvent.Error
 	}
 }
+
+// StreamRequestHeader carries the JSON encoded request of a stream call, whose body is the raw file content
+const StreamRequestHeader = "x-polycode-stream-request"
+
+// ChecksumTrailer carries the hex encoded SHA-256 of a streamed upload body, sent once the body is fully read
+const ChecksumTrailer = "x-polycode-checksum-sha256"
+
+func executeStreamUpload(httpClient *http.Client, baseUrl string, sessionId string, path string, req any, body io.Reader) error {
+	return executeStreamUploadWithResponse(httpClient, baseUrl, sessionId, path, req, body, nil)
+}
+
+// executeStreamUploadWithResponse streams body like executeStreamUpload and decodes the JSON response into res, if not nil
+func executeStreamUploadWithResponse(httpClient *http.Client, baseUrl string, sessionId string, path string, req any, body io.Reader, res any) error {
+	log.Printf("client: exec stream upload to %s with session id %s", path, sessionId)
+
+	reqHeader, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	httpReq, err := http.NewRequestWithContext(Invocations.Context(sessionId), http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), io.TeeReader(body, hash))
+	if err != nil {
+		return err
+	}
+
+	// an unknown content length makes the transport send the body chunked, which also allows the
+	// checksum to follow as a trailer once the transport has read the whole body
+	httpReq.ContentLength = -1
+	httpReq.Trailer = http.Header{http.CanonicalHeaderKey(ChecksumTrailer): nil}
+	httpReq.Body = &checksumBody{
+		ReadCloser: httpReq.Body,
+		onEOF: func() {
+			httpReq.Trailer.Set(ChecksumTrailer, hex.EncodeToString(hash.Sum(nil)))
+		},
+	}
+	httpReq.Header.Set("Content-Type", "application/octet-stream")
+	httpReq.Header.Set(StreamRequestHeader, string(reqHeader))
+	httpReq.Header.Set("x-polycode-task-session-id", sessionId)
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeErrorEvent(resp)
+	}
+
+	if res != nil {
+		return json.NewDecoder(resp.Body).Decode(res)
+	}
+
+	return nil
+}
+
+// checksumBody calls onEOF once the wrapped body is exhausted, before the transport writes the trailers
+type checksumBody struct {
+	io.ReadCloser
+	onEOF func()
+	done  bool
+}
+
+func (b *checksumBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF && !b.done {
+		b.done = true
+		b.onEOF()
+	}
+	return n, err
+}
+
+func executeStreamDownload(httpClient *http.Client, baseUrl string, sessionId string, path string, req any) (io.ReadCloser, error) {
+	log.Printf("client: exec stream download from %s with session id %s", path, sessionId)
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(Invocations.Context(sessionId), http.MethodPost, fmt.Sprintf("%s/%s", baseUrl, path), bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-polycode-task-session-id", sessionId)
+
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, ErrFileNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, decodeErrorEvent(resp)
+	}
+
+	return resp.Body, nil
+}
+
+func decodeErrorEvent(resp *http.Response) error {
+	errorEvent := ErrorEvent{}
+	err := json.NewDecoder(resp.Body).Decode(&errorEvent)
+	if err != nil {
+		return fmt.Errorf("http error, status: %v", resp.Status)
+	}
+	return errorEvent.Error
+}
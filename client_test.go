@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"github.com/cloudimpl/polycode-sdk-go"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
 	"time"
 )
 
 func startMockBackendServer(port int) {
+	newMockBackendRouter().Run(getAddr(port))
+}
+
+// newMockBackendRouter serves canned sidecar responses for every route the service client calls
+func newMockBackendRouter() *gin.Engine {
 	router := gin.Default()
 
 	// Health check
@@ -18,12 +24,36 @@ func startMockBackendServer(port int) {
 
 	// --- App & Service Execution ---
 	router.POST("/v1/system/app/start", empty200)
+	router.POST("/v1/system/app/stop", empty200)
+	router.POST("/v1/system/app/versions", func(c *gin.Context) {
+		c.JSON(200, VersionMarkersResponse{
+			Markers: []VersionMarker{{Method: "orders.Place", ChangeId: "new-tax", Version: 1, Executions: 2}},
+		})
+	})
 	router.POST("/v1/context/service/exec", func(c *gin.Context) {
 		c.JSON(200, ExecServiceResponse{
 			IsAsync: false,
 			Output:  map[string]any{"service": "ok"},
 		})
 	})
+	router.POST("/v1/context/service/exec-batch", func(c *gin.Context) {
+		var req ExecServiceBatchRequest
+		_ = c.ShouldBindJSON(&req)
+
+		responses := make([]ExecServiceResponse, len(req.Requests))
+		for i := range responses {
+			responses[i] = ExecServiceResponse{
+				IsAsync: false,
+				Output:  map[string]any{"service": "ok", "index": i},
+			}
+		}
+
+		c.JSON(200, ExecServiceBatchResponse{
+			IsAsync:   false,
+			BatchId:   "batch-1",
+			Responses: responses,
+		})
+	})
 	router.POST("/v1/context/app/exec", func(c *gin.Context) {
 		c.JSON(200, ExecAppResponse{
 			IsAsync: false,
@@ -65,14 +95,32 @@ func startMockBackendServer(port int) {
 		c.JSON(200, GetFileResponse{Content: "mock-content"})
 	})
 	router.POST("/v1/context/file/get-download-link", func(c *gin.Context) {
-		c.JSON(200, GetLinkResponse{Link: "http://mock.link/download"})
+		c.JSON(200, GetLinkResponse{Link: "http://mock.link/download", Method: http.MethodGet, ExpiresAt: time.Now().Add(time.Hour).UnixMilli()})
+	})
+	router.POST("/v1/context/file/get-stream", func(c *gin.Context) {
+		c.Data(200, "application/octet-stream", []byte("mock-content"))
 	})
 	router.POST("/v1/context/file/put", empty200)
+	router.POST("/v1/context/file/put-stream", func(c *gin.Context) {
+		_, _ = io.Copy(io.Discard, c.Request.Body)
+		c.Status(http.StatusOK)
+	})
 	router.POST("/v1/context/file/get-upload-link", func(c *gin.Context) {
 		c.JSON(200, GetLinkResponse{Link: "http://mock.link/upload"})
 	})
 	router.POST("/v1/context/file/delete", empty200)
 	router.POST("/v1/context/file/rename", empty200)
+	router.POST("/v1/context/file/copy", empty200)
+	router.POST("/v1/context/file/promote", empty200)
+	router.POST("/v1/context/file/versioning", empty200)
+	router.POST("/v1/context/file/versions", func(c *gin.Context) {
+		c.JSON(200, ListFileVersionsResponse{Versions: []FileVersion{{VersionId: "v1", Size: 123, IsLatest: true}}})
+	})
+	router.POST("/v1/context/file/restore-version", empty200)
+	router.POST("/v1/context/file/undelete", empty200)
+	router.POST("/v1/context/file/stat", func(c *gin.Context) {
+		c.JSON(200, StatFileResponse{Exists: true, Key: "file1.txt", Size: 123, ContentType: "text/plain"})
+	})
 	router.POST("/v1/context/file/list", func(c *gin.Context) {
 		c.JSON(200, ListFilePageResponse{
 			Files: []ListFileResponse{{
@@ -85,6 +133,21 @@ func startMockBackendServer(port int) {
 		})
 	})
 	router.POST("/v1/context/file/create-folder", empty200)
+	router.POST("/v1/context/file/multipart/create", func(c *gin.Context) {
+		c.JSON(200, CreateMultipartUploadResponse{UploadId: "mock-upload"})
+	})
+	router.POST("/v1/context/file/multipart/part-link", func(c *gin.Context) {
+		c.JSON(200, GetLinkResponse{Link: "http://mock.link/part", Method: http.MethodPut})
+	})
+	router.POST("/v1/context/file/multipart/put-part", func(c *gin.Context) {
+		n, _ := io.Copy(io.Discard, c.Request.Body)
+		c.JSON(200, UploadedPart{PartNumber: 1, ETag: "mock-etag", Size: n})
+	})
+	router.POST("/v1/context/file/multipart/list-parts", func(c *gin.Context) {
+		c.JSON(200, ListFilePartsResponse{})
+	})
+	router.POST("/v1/context/file/multipart/complete", empty200)
+	router.POST("/v1/context/file/multipart/abort", empty200)
 
 	// --- Signal & Event ---
 	router.POST("/v1/context/signal/emit", empty200)
@@ -94,8 +157,27 @@ func startMockBackendServer(port int) {
 			Output:  "signal-output",
 		})
 	})
+	router.POST("/v1/context/signal/select", func(c *gin.Context) {
+		c.JSON(200, SignalSelectResponse{
+			IsAsync:    false,
+			SignalName: "signal-a",
+			Output:     "signal-output",
+		})
+	})
 	router.POST("/v1/context/realtime/event/emit", empty200)
 
+	// --- Task ---
+	router.POST("/v1/context/task/await", func(c *gin.Context) {
+		c.JSON(200, TaskAwaitResponse{
+			IsAsync: false,
+			Output:  "task-output",
+		})
+	})
+	router.POST("/v1/context/task/status", func(c *gin.Context) {
+		c.JSON(200, TaskStatusResponse{Status: TaskRunning})
+	})
+	router.POST("/v1/context/task/cancel", empty200)
+
 	// --- Lock ---
 	router.POST("/v1/context/lock/acquire", empty200)
 	router.POST("/v1/context/lock/release", empty200)
@@ -114,7 +196,7 @@ func startMockBackendServer(port int) {
 	// --- Misc ---
 	router.POST("/v1/context/acknowledge", empty200)
 
-	router.Run(getAddr(port))
+	return router
 }
 
 func getAddr(port int) string {
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nondeterministic lists the calls that return different values on every replay of a workflow,
+// keyed by import path. An empty list flags every function of the package.
+var nondeterministic = map[string][]string{
+	"time":                   {"Now", "Since", "Until", "Sleep", "After", "Tick", "NewTimer", "NewTicker", "AfterFunc"},
+	"math/rand":              {},
+	"math/rand/v2":           {},
+	"crypto/rand":            {},
+	"github.com/google/uuid": {"New", "NewString", "NewRandom", "NewV7", "Must"},
+}
+
+var replacements = map[string]string{
+	"time":                   "ctx.Now()",
+	"math/rand":              "ctx.Rand()",
+	"math/rand/v2":           "ctx.Rand()",
+	"crypto/rand":            "ctx.Memo(...)",
+	"github.com/google/uuid": "ctx.NewUUID()",
+}
+
+// memoizers are the context methods whose getter result is recorded and replayed
+var memoizers = map[string]bool{
+	"Memo":          true,
+	"MemoKey":       true,
+	"MemoWithRetry": true,
+}
+
+type Finding struct {
+	Pos     token.Position
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Pos, f.Message)
+}
+
+// CheckFile reports nondeterministic calls made inside functions that take a polycode.WorkflowContext
+func CheckFile(fset *token.FileSet, file *ast.File) []Finding {
+	// local package names by import path, two imports can share a name when one of them is not aliased
+	imports := make(map[string]string)
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := nondeterministic[path]; !ok {
+			continue
+		}
+
+		name := lastElement(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[path] = name
+	}
+
+	var findings []Finding
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || !isWorkflow(fn) {
+			continue
+		}
+
+		var inspect func(n ast.Node) bool
+		inspect = func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.GoStmt:
+				findings = append(findings, Finding{
+					Pos:     fset.Position(node.Pos()),
+					Message: fmt.Sprintf("goroutine started in workflow %s, use ctx.Parallel() instead", fn.Name.Name),
+				})
+			case *ast.CallExpr:
+				sel, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+
+				// a getter runs once and its result is recorded, so it may call anything
+				if memoizers[sel.Sel.Name] {
+					ast.Inspect(node.Fun, inspect)
+					for _, arg := range node.Args {
+						if _, ok := arg.(*ast.FuncLit); !ok {
+							ast.Inspect(arg, inspect)
+						}
+					}
+					return false
+				}
+
+				pkg, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+
+				paths := resolve(imports, pkg.Name, sel.Sel.Name)
+				if len(paths) == 0 {
+					return true
+				}
+
+				var use []string
+				for _, path := range paths {
+					use = append(use, replacements[path])
+				}
+
+				findings = append(findings, Finding{
+					Pos: fset.Position(node.Pos()),
+					Message: fmt.Sprintf("nondeterministic call %s.%s in workflow %s, use %s instead",
+						pkg.Name, sel.Sel.Name, fn.Name.Name, strings.Join(use, " or ")),
+				})
+			}
+			return true
+		}
+		ast.Inspect(fn.Body, inspect)
+	}
+
+	return findings
+}
+
+func isWorkflow(fn *ast.FuncDecl) bool {
+	for _, field := range fn.Type.Params.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if ok && sel.Sel.Name == "WorkflowContext" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the import paths, in sorted order, that are imported under name and flag the function
+func resolve(imports map[string]string, name string, fn string) []string {
+	var paths []string
+	for path, imported := range imports {
+		if imported == name && matches(path, fn) {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+func matches(path string, name string) bool {
+	funcs := nondeterministic[path]
+	if len(funcs) == 0 {
+		return true
+	}
+
+	for _, f := range funcs {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func lastElement(path string) string {
+	if path == "math/rand/v2" {
+		return "rand"
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '/' {
+			return path[i+1:]
+		}
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const src = `package app
+
+import (
+	"github.com/cloudimpl/polycode-sdk-go"
+	mrand "math/rand"
+	"time"
+)
+
+func (s *Orders) Place(ctx polycode.WorkflowContext, input PlaceInput) (any, error) {
+	now := time.Now()
+	n := mrand.Intn(10)
+	d := time.Duration(n) * time.Second
+	go notify()
+	return now.Add(d), nil
+}
+
+func (s *Orders) Lookup(ctx polycode.ServiceContext, id string) (any, error) {
+	return time.Now(), nil
+}
+`
+
+func TestCheckFile(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "orders.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	findings := CheckFile(fset, file)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %d: %v", len(findings), findings)
+	}
+
+	if !strings.Contains(findings[0].Message, "time.Now") || findings[0].Pos.Line != 10 {
+		t.Fatalf("unexpected first finding %s", findings[0])
+	}
+	if !strings.Contains(findings[1].Message, "mrand.Intn") || !strings.Contains(findings[1].Message, "ctx.Rand()") {
+		t.Fatalf("unexpected second finding %s", findings[1])
+	}
+	if !strings.Contains(findings[2].Message, "goroutine") {
+		t.Fatalf("unexpected third finding %s", findings[2])
+	}
+}
+
+const collidingSrc = `package app
+
+import (
+	"crypto/rand"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"math/rand"
+)
+
+func (s *Orders) Place(ctx polycode.WorkflowContext, input PlaceInput) (any, error) {
+	return rand.Intn(10), nil
+}
+`
+
+func TestCheckFile_CollidingImportNames(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "orders.go", collidingSrc, 0)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	findings := CheckFile(fset, file)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %v", len(findings), findings)
+	}
+
+	if !strings.Contains(findings[0].Message, "ctx.Rand()") || !strings.Contains(findings[0].Message, "ctx.Memo(...)") {
+		t.Fatalf("expected both rand packages to be kept, got %s", findings[0])
+	}
+}
+
+const memoSrc = `package app
+
+import (
+	"github.com/cloudimpl/polycode-sdk-go"
+	"time"
+)
+
+func (s *Orders) Place(ctx polycode.WorkflowContext, input PlaceInput) (any, error) {
+	res := ctx.MemoKey("created", func() (any, error) {
+		return time.Now(), nil
+	})
+	ctx.MemoKey(time.Now().String(), func() (any, error) {
+		return nil, nil
+	})
+	return res, nil
+}
+`
+
+func TestCheckFile_SkipsMemoGetters(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "orders.go", memoSrc, 0)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	// only the key computed outside the getter is reported
+	findings := CheckFile(fset, file)
+	if len(findings) != 1 || findings[0].Pos.Line != 12 {
+		t.Fatalf("expected 1 finding on line 12, got %v", findings)
+	}
+}
@@ -0,0 +1,79 @@
+// Command polycode-vet reports calls that make workflow methods nondeterministic on replay,
+// such as time.Now, math/rand and uuid.New, and suggests the replay safe Context helpers.
+//
+// Usage:
+//
+//	polycode-vet [dir | dir/...]...
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = []string{"./..."}
+	}
+
+	fset := token.NewFileSet()
+	count := 0
+	for _, dir := range dirs {
+		files, err := goFiles(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "polycode-vet: %s\n", err.Error())
+			os.Exit(2)
+		}
+
+		for _, path := range files {
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "polycode-vet: %s\n", err.Error())
+				os.Exit(2)
+			}
+
+			for _, finding := range CheckFile(fset, file) {
+				fmt.Println(finding.String())
+				count++
+			}
+		}
+	}
+
+	if count > 0 {
+		os.Exit(1)
+	}
+}
+
+func goFiles(dir string) ([]string, error) {
+	recursive := strings.HasSuffix(dir, "/...")
+	if recursive {
+		dir = strings.TrimSuffix(dir, "/...")
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && (!recursive || d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files, err
+}
@@ -23,7 +23,11 @@ func (c Context) Done() <-chan struct{} {
 }
 
 func (c Context) Err() error {
-	return c.ctx.Err()
+	err := c.ctx.Err()
+	if err != nil {
+		captureDeadlineStack(c.ctx)
+	}
+	return err
 }
 
 func (c Context) Value(key any) any {
@@ -67,15 +71,26 @@ func (c Context) FileStore() polycode.Folder {
 	}
 }
 
+// TempFileStore returns the temp folder shared by every task of the app. Its files are not cleaned up
+// when a task completes, they are removed once their TTL expires, so scratch files of a single task
+// belong in SessionTempFileStore. The local file store does not expire files.
 func (c Context) TempFileStore() polycode.Folder {
 	return Folder{
 		sessionId: c.sessionId,
 		client:    c.client,
 		parent:    nil,
 		name:      "temp-files",
+		temp:      true,
 	}
 }
 
+// SessionTempFileStore returns a temp folder private to this task, it is deleted with everything in it
+// once the task completes, whether it succeeded or failed
+func (c Context) SessionTempFileStore() polycode.Folder {
+	markSessionTempUsed(c.sessionId)
+	return sessionTempFolder(c.client, c.sessionId)
+}
+
 func (c Context) Service(service string) polycode.ServiceBuilder {
 	return ServiceBuilder{
 		ctx:           c.ctx,
@@ -115,13 +130,41 @@ func (c Context) AgentEx(envId string, agent string) polycode.AgentBuilder {
 }
 
 func (c Context) App(appName string) polycode.Service {
-	// new method added with v2
-	panic("implement me")
+	return App{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		appName:       appName,
+		serviceClient: c.client,
+	}
 }
 
 func (c Context) AppEx(envId string, appName string) polycode.Service {
-	// new method added with v2
-	panic("implement me")
+	return App{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		envId:         envId,
+		appName:       appName,
+		serviceClient: c.client,
+	}
+}
+
+// Parallel creates a group of child service and agent calls that are started together and awaited as a set
+func (c Context) Parallel() *FutureGroup {
+	return &FutureGroup{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		serviceClient: c.client,
+	}
+}
+
+// Saga creates a saga that runs the compensations of completed steps in reverse order on failure
+func (c Context) Saga(name string) *Saga {
+	return &Saga{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		name:          name,
+		serviceClient: c.client,
+	}
 }
 
 func (c Context) Controller(controller string) polycode.Controller {
@@ -143,6 +186,13 @@ func (c Context) ControllerEx(envId string, controller string) polycode.Controll
 	}
 }
 
+// Detached runs fn with the sidecar calls of this invocation detached from its cancellation, so cleanup
+// and compensations started after a cancel can complete. The calls still end with the invocation deadline.
+func (c Context) Detached(fn func() error) error {
+	defer Invocations.Detach(c.sessionId)()
+	return fn()
+}
+
 func (c Context) Memo(getter func() (any, error)) polycode.Response {
 	return Memo{
 		ctx:           c.ctx,
@@ -152,9 +202,45 @@ func (c Context) Memo(getter func() (any, error)) polycode.Response {
 	}.Get()
 }
 
+// MemoKey is like Memo but records the key with the result and fails on replay if the recorded key differs
+func (c Context) MemoKey(key string, getter func() (any, error)) polycode.Response {
+	return Memo{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		serviceClient: c.client,
+		key:           key,
+		getter:        getter,
+	}.Get()
+}
+
+// MemoWithRetry is like MemoKey but retries a failing getter according to the policy before the failure is recorded
+func (c Context) MemoWithRetry(key string, policy RetryPolicy, getter func() (any, error)) polycode.Response {
+	return Memo{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		serviceClient: c.client,
+		key:           key,
+		retryPolicy:   &policy,
+		getter:        getter,
+	}.Get()
+}
+
 func (c Context) Signal(signalName string) polycode.Signal {
-	// not implemented, old architecture is not good
-	panic("implement me")
+	return Signal{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		name:          signalName,
+		serviceClient: c.client,
+	}
+}
+
+func (c Context) SelectSignal(signalNames ...string) SignalSelector {
+	return SignalSelector{
+		ctx:           c.ctx,
+		sessionId:     c.sessionId,
+		names:         signalNames,
+		serviceClient: c.client,
+	}
 }
 
 func (c Context) ClientChannel(channelName string) polycode.ClientChannel {
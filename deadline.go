@@ -0,0 +1,89 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxDeadlineStackSize bounds the handler stack captured when a task runs past its deadline
+const maxDeadlineStackSize = 64 * 1024
+
+// deadlineFor returns the earliest of the deadline carried by the event, set by the sidecar from the
+// caller's task options, and the timeout registered for the method. The registered timeout counts from
+// the first start of the task, so a workflow re-executed after a suspension keeps its original deadline.
+func (c ClientRuntime) deadlineFor(event ServiceStartEvent) (time.Time, bool) {
+	var deadline time.Time
+	if event.Deadline > 0 {
+		deadline = time.UnixMilli(event.Deadline)
+	}
+
+	if timeout, ok := c.timeoutMap[event.Service+"."+event.Method]; ok {
+		start := time.Now()
+		if event.StartedAt > 0 {
+			start = time.UnixMilli(event.StartedAt)
+		}
+
+		registered := start.Add(timeout)
+		if deadline.IsZero() || registered.Before(deadline) {
+			deadline = registered
+		}
+	}
+
+	return deadline, !deadline.IsZero()
+}
+
+type deadlineWatcherKey struct{}
+
+// deadlineWatcher keeps the stack of the handler goroutine at the point it first ran into the expired
+// deadline of its task, so the timeout can be reported with the point the handler had reached. The
+// stack is taken on the handler goroutine itself, when a sidecar call fails with the deadline, the
+// handler checks Err or a panic unwinds, so other invocations never end up in the report.
+type deadlineWatcher struct {
+	ctx   context.Context
+	mu    sync.Mutex
+	stack string
+}
+
+// watchDeadline returns ctx carrying a watcher for its deadline
+func watchDeadline(ctx context.Context) (context.Context, *deadlineWatcher) {
+	w := &deadlineWatcher{}
+	ctx = context.WithValue(ctx, deadlineWatcherKey{}, w)
+	w.ctx = ctx
+	return ctx, w
+}
+
+// capture records the stack of the calling goroutine if the deadline expired and no stack was recorded yet
+func (w *deadlineWatcher) capture() {
+	if !errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stack != "" {
+		return
+	}
+
+	buf := make([]byte, maxDeadlineStackSize)
+	n := runtime.Stack(buf, false)
+	w.stack = string(buf[:n])
+}
+
+// Stack returns the captured stack, empty if the deadline did not expire
+func (w *deadlineWatcher) Stack() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stack
+}
+
+// captureDeadlineStack records the stack of the calling goroutine on the deadline watcher carried by ctx, if any
+func captureDeadlineStack(ctx context.Context) {
+	if w, ok := ctx.Value(deadlineWatcherKey{}).(*deadlineWatcher); ok {
+		w.capture()
+	}
+}
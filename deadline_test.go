@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadlineFor_PicksEarliest(t *testing.T) {
+	c := ClientRuntime{
+		timeoutMap: map[string]time.Duration{"orders.Place": time.Minute},
+	}
+
+	callerDeadline := time.Now().Add(time.Second)
+	deadline, ok := c.deadlineFor(ServiceStartEvent{Service: "orders", Method: "Place", Deadline: callerDeadline.UnixMilli()})
+	if !ok || deadline.UnixMilli() != callerDeadline.UnixMilli() {
+		t.Fatalf("expected caller deadline %v, got %v", callerDeadline, deadline)
+	}
+
+	deadline, ok = c.deadlineFor(ServiceStartEvent{Service: "orders", Method: "Place"})
+	if !ok || time.Until(deadline) < 59*time.Second {
+		t.Fatalf("expected registered timeout, got %v", deadline)
+	}
+
+	if _, ok = c.deadlineFor(ServiceStartEvent{Service: "orders", Method: "Get"}); ok {
+		t.Fatalf("expected no deadline")
+	}
+}
+
+func TestDeadlineFor_AnchoredToFirstStart(t *testing.T) {
+	c := ClientRuntime{
+		timeoutMap: map[string]time.Duration{"orders.Place": time.Minute},
+	}
+
+	startedAt := time.Now().Add(-50 * time.Second)
+	deadline, ok := c.deadlineFor(ServiceStartEvent{Service: "orders", Method: "Place", StartedAt: startedAt.UnixMilli()})
+	if !ok || deadline.UnixMilli() != startedAt.Add(time.Minute).UnixMilli() {
+		t.Fatalf("expected deadline a minute after the first start, got %v", deadline)
+	}
+}
+
+func blockedHandler(ctx context.Context) {
+	<-ctx.Done()
+	captureDeadlineStack(ctx)
+}
+
+func TestDeadlineWatcher_CapturesHandlerStack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	ctx, w := watchDeadline(ctx)
+
+	// another goroutine that must not show up in the captured stack
+	other := make(chan struct{})
+	defer close(other)
+	go func() {
+		<-other
+	}()
+
+	blockedHandler(ctx)
+
+	stack := w.Stack()
+	if !strings.Contains(stack, "blockedHandler") {
+		t.Fatalf("expected handler frames in stack, got %q", stack)
+	}
+	if strings.Contains(stack, "chan receive") {
+		t.Fatalf("expected only the handler goroutine, got %q", stack)
+	}
+
+	ctx, w = watchDeadline(context.Background())
+	captureDeadlineStack(ctx)
+	if stack := w.Stack(); stack != "" {
+		t.Fatalf("expected no stack without deadline, got %q", stack)
+	}
+}
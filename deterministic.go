@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"crypto/rand"
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"time"
+)
+
+// Values are recorded as strings so large integers survive the JSON round trip to the sidecar unchanged.
+// Each helper records under its own memo key, so replaying a history that recorded a different call at
+// the same position fails instead of returning a value of the wrong kind.
+
+// Now returns the current time, recorded on first execution and replayed afterwards
+func (c Context) Now() (time.Time, error) {
+	var recorded string
+	err := c.MemoKey("now", func() (any, error) {
+		return time.Now().UTC().Format(time.RFC3339Nano), nil
+	}).Get(&recorded)
+	if err != nil {
+		return time.Time{}, ErrTaskExecError.Wrap(err)
+	}
+
+	now, err := time.Parse(time.RFC3339Nano, recorded)
+	if err != nil {
+		return time.Time{}, ErrTaskExecError.Wrap(err)
+	}
+
+	return now, nil
+}
+
+// NewUUID returns a random (version 4) UUID, recorded on first execution and replayed afterwards
+func (c Context) NewUUID() (string, error) {
+	var recorded string
+	err := c.MemoKey("uuid", func() (any, error) {
+		return newUUID()
+	}).Get(&recorded)
+	if err != nil {
+		return "", ErrTaskExecError.Wrap(err)
+	}
+
+	return recorded, nil
+}
+
+// Rand returns a random source whose seed is recorded on first execution, so the
+// same sequence of values is produced on every re-execution
+func (c Context) Rand() (*mathrand.Rand, error) {
+	var recorded string
+	err := c.MemoKey("rand-seed", func() (any, error) {
+		return strconv.FormatInt(time.Now().UnixNano(), 10), nil
+	}).Get(&recorded)
+	if err != nil {
+		return nil, ErrTaskExecError.Wrap(err)
+	}
+
+	seed, err := strconv.ParseInt(recorded, 10, 64)
+	if err != nil {
+		return nil, ErrTaskExecError.Wrap(err)
+	}
+
+	return mathrand.New(mathrand.NewSource(seed)), nil
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+// replay returns a context whose ExecFunc replays the single value recorded by first
+func replay(t *testing.T, first *memoServiceClient) Context {
+	if len(first.results) != 1 {
+		t.Fatalf("expected one recorded value, got %+v", first.results)
+	}
+
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: true,
+			Key:         first.results[0].Key,
+			Output:      first.results[0].Output,
+		},
+	}
+	return Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+}
+
+func TestNow_ReplaysRecordedTime(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	recorded, err := c.Now()
+	if err != nil {
+		t.Fatalf("Now failed: %v", err)
+	}
+
+	replayed, err := replay(t, mock).Now()
+	if err != nil || !replayed.Equal(recorded) {
+		t.Fatalf("expected replayed time %v, got %v (err=%v)", recorded, replayed, err)
+	}
+}
+
+func TestNewUUID_ReplaysRecordedValue(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	recorded, err := c.NewUUID()
+	if err != nil || len(recorded) != 36 || recorded[14] != '4' {
+		t.Fatalf("unexpected uuid %q (err=%v)", recorded, err)
+	}
+
+	replayed, err := replay(t, mock).NewUUID()
+	if err != nil || replayed != recorded {
+		t.Fatalf("expected replayed uuid %q, got %q (err=%v)", recorded, replayed, err)
+	}
+}
+
+func TestRand_ReplaysRecordedSeed(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	recorded, err := c.Rand()
+	if err != nil {
+		t.Fatalf("Rand failed: %v", err)
+	}
+
+	replayed, err := replay(t, mock).Rand()
+	if err != nil {
+		t.Fatalf("Rand replay failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if a, b := recorded.Int63(), replayed.Int63(); a != b {
+			t.Fatalf("replayed sequence diverged at %d: %d != %d", i, a, b)
+		}
+	}
+}
+
+func TestNow_InvalidRecordedValueFails(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: true,
+			Key:         "now",
+			Output:      "not a time",
+		},
+	}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	if _, err := c.Now(); err == nil {
+		t.Fatalf("expected an error for an invalid recorded time")
+	}
+}
+
+func TestNow_ReplayOfOtherHelperFails(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	if _, err := c.NewUUID(); err != nil {
+		t.Fatalf("NewUUID failed: %v", err)
+	}
+
+	// the workflow now calls Now where it used to call NewUUID
+	if _, err := replay(t, mock).Now(); err == nil {
+		t.Fatalf("expected replaying a recorded uuid as a time to fail")
+	}
+}
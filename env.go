@@ -1,7 +1,10 @@
 package runtime
 
 type ClientEnv struct {
-	AppName    string `json:"appName"`
-	AppPort    uint   `json:"appPort"`
-	SidecarApi string `json:"sidecarApi"`
+	AppName     string            `json:"appName"`
+	AppPort     uint              `json:"appPort"`
+	SidecarApi  string            `json:"sidecarApi"`
+	Concurrency ConcurrencyConfig `json:"concurrency"`
+	Shutdown    ShutdownConfig    `json:"shutdown"`
+	FileStore   FileStoreConfig   `json:"fileStore"`
 }
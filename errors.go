@@ -9,4 +9,27 @@ var ErrServiceExecError = errors.DefineError("polycode.client", 4, "service exec
 var ErrApiExecError = errors.DefineError("polycode.client", 5, "api exec error")
 var ErrBadRequest = errors.DefineError("polycode.client", 6, "bad request")
 var ErrTaskExecError = errors.DefineError("polycode.client", 7, "task execution error")
+var ErrSignalTimeout = errors.DefineError("polycode.client", 8, "signal wait timed out, signal: [%s]")
+var ErrFuturePending = errors.DefineError("polycode.client", 9, "future is not completed")
+var ErrFutureGroupEmpty = errors.DefineError("polycode.client", 10, "future group has no calls")
+var ErrFutureGroupStarted = errors.DefineError("polycode.client", 11, "future group already started")
+var ErrSagaAborted = errors.DefineError("polycode.client", 12, "saga aborted, saga: [%s]")
+var ErrSagaCompensated = errors.DefineError("polycode.client", 13, "saga already compensated, saga: [%s]")
+var ErrSagaCompensationFailed = errors.DefineError("polycode.client", 14, "saga compensation failed, saga: [%s]")
+var ErrVersionNotSupported = errors.DefineError("polycode.client", 15, "workflow version not supported, version: [%s]")
+var ErrMemoKeyMismatch = errors.DefineError("polycode.client", 16, "memo key mismatch on replay, %s")
+var ErrTaskCancelled = errors.DefineError("polycode.client", 17, "task cancelled, reason: [%s]")
+var ErrOverloaded = errors.DefineError("polycode.client", 18, "app overloaded, retry later")
+var ErrTaskTimeout = errors.DefineError("polycode.client", 19, "task deadline exceeded, deadline: [%s]")
+var ErrFileNotFound = errors.DefineError("polycode.client", 20, "file not found")
+var ErrFileTooLarge = errors.DefineError("polycode.client", 21, "file exceeds size limit of %d bytes")
+var ErrInvalidLinkOptions = errors.DefineError("polycode.client", 22, "invalid link options, %s")
+var ErrMultipartUploadFailed = errors.DefineError("polycode.client", 23, "multipart upload failed, upload id: [%s]")
+var ErrUnsupportedArchiveFormat = errors.DefineError("polycode.client", 24, "unsupported archive format: [%s]")
+var ErrUnsafeArchivePath = errors.DefineError("polycode.client", 25, "archive entry escapes the target folder: [%s]")
+var ErrArchiveTooLarge = errors.DefineError("polycode.client", 26, "archive exceeds decompressed size limit of %d bytes")
+var ErrNotTempFile = errors.DefineError("polycode.client", 27, "file is not in the temp file store: [%s]")
+var ErrLocalFileStoreUnsupported = errors.DefineError("polycode.client", 28, "not supported by the local file store: [%s]")
+var ErrChecksumMismatch = errors.DefineError("polycode.client", 29, "file checksum mismatch: [%s]")
+var ErrSignalTimeoutUnsupported = errors.DefineError("polycode.client", 30, "signal does not support a wait timeout: [%s]")
 var ErrTaskStopped = &ErrPanic
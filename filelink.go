@@ -0,0 +1,137 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// LinkOptions controls a presigned link, the zero value asks for a default link
+type LinkOptions struct {
+	// Expiry is how long the link stays valid, 0 uses the sidecar default
+	Expiry time.Duration
+	// Method the link is signed for, GET or HEAD for download links and PUT or POST for upload links
+	Method string
+	// ContentDisposition is returned with the download, see AttachmentDisposition
+	ContentDisposition string
+	// ContentType overrides the content type of a download, or is the only content type an upload accepts
+	ContentType string
+	// MaxSize is the largest upload the link accepts in bytes, 0 means unlimited
+	MaxSize int64
+}
+
+// Link is a presigned link to a file
+type Link struct {
+	Url       string
+	Method    string
+	ExpiresAt time.Time
+	// Headers must be sent with the request for the signature to match
+	Headers map[string]string
+}
+
+// AttachmentDisposition returns a Content-Disposition that makes browsers save the download as filename
+func AttachmentDisposition(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// GetDownloadLinkWithOptions returns a presigned link to read the file
+func (f File) GetDownloadLinkWithOptions(options LinkOptions) (Link, error) {
+	if err := options.validate(http.MethodGet, http.MethodGet, http.MethodHead); err != nil {
+		return Link{}, err
+	}
+
+	if options.MaxSize != 0 {
+		return Link{}, ErrInvalidLinkOptions.With("max size only applies to upload links")
+	}
+
+	req := GetDownloadLinkRequest{
+		Key:                f.Path(),
+		Expiry:             options.Expiry.Milliseconds(),
+		Method:             options.Method,
+		ContentDisposition: options.ContentDisposition,
+		ContentType:        options.ContentType,
+	}
+
+	res, err := f.client.GetFileDownloadLink(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to get file link: %s\n", err.Error())
+		return Link{}, err
+	}
+
+	return toLink(res, req.Method)
+}
+
+// GetUploadLinkWithOptions returns a presigned link to write the file
+func (f File) GetUploadLinkWithOptions(options LinkOptions) (Link, error) {
+	if err := options.validate(http.MethodPut, http.MethodPut, http.MethodPost); err != nil {
+		return Link{}, err
+	}
+
+	if options.ContentDisposition != "" {
+		return Link{}, ErrInvalidLinkOptions.With("content disposition only applies to download links")
+	}
+
+	req := GetUploadLinkRequest{
+		Key:         f.Path(),
+		TempFile:    f.temp,
+		Expiry:      options.Expiry.Milliseconds(),
+		Method:      options.Method,
+		ContentType: options.ContentType,
+		MaxSize:     options.MaxSize,
+	}
+
+	res, err := f.client.GetFileUploadLink(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to get file link: %s\n", err.Error())
+		return Link{}, err
+	}
+
+	return toLink(res, req.Method)
+}
+
+// validate fills in the default method and rejects a method the link kind does not support
+func (o *LinkOptions) validate(defaultMethod string, allowed ...string) error {
+	if o.Expiry < 0 {
+		return ErrInvalidLinkOptions.With("negative expiry")
+	}
+
+	if o.MaxSize < 0 {
+		return ErrInvalidLinkOptions.With("negative max size")
+	}
+
+	if o.Method == "" {
+		o.Method = defaultMethod
+	}
+
+	for _, method := range allowed {
+		if o.Method == method {
+			return nil
+		}
+	}
+
+	return ErrInvalidLinkOptions.With("unsupported method " + o.Method)
+}
+
+func toLink(res GetLinkResponse, method string) (Link, error) {
+	if res.Link == "" {
+		return Link{}, errors.New("empty link")
+	}
+
+	link := Link{
+		Url:     res.Link,
+		Method:  method,
+		Headers: res.Headers,
+	}
+
+	if res.Method != "" {
+		link.Method = res.Method
+	}
+
+	if res.ExpiresAt > 0 {
+		link.ExpiresAt = time.UnixMilli(res.ExpiresAt)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFile_LinkOptions(t *testing.T) {
+	client := newFileServiceClient(nil)
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("report.pdf").(File)
+
+	link, err := f.GetDownloadLinkWithOptions(LinkOptions{
+		Expiry:             5 * time.Minute,
+		ContentDisposition: AttachmentDisposition("Q3 report.pdf"),
+	})
+	if err != nil {
+		t.Fatalf("download link failed: %v", err)
+	}
+	if client.download.Expiry != 300000 || client.download.Method != http.MethodGet {
+		t.Fatalf("unexpected request %+v", client.download)
+	}
+	if client.download.ContentDisposition != `attachment; filename="Q3 report.pdf"` {
+		t.Fatalf("unexpected disposition %s", client.download.ContentDisposition)
+	}
+	if link.Method != http.MethodGet || !link.ExpiresAt.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("unexpected link %+v", link)
+	}
+
+	link, err = f.GetUploadLinkWithOptions(LinkOptions{ContentType: "application/pdf", MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("upload link failed: %v", err)
+	}
+	if client.upload.Method != http.MethodPut || client.upload.MaxSize != 1<<20 || link.Headers["Content-Type"] != "application/pdf" {
+		t.Fatalf("unexpected upload link %+v for request %+v", link, client.upload)
+	}
+
+	if _, err := f.GetUploadLinkWithOptions(LinkOptions{Method: http.MethodGet}); err == nil {
+		t.Fatalf("expected upload link with GET to be rejected")
+	}
+	if _, err := f.GetDownloadLinkWithOptions(LinkOptions{MaxSize: 10}); err == nil {
+		t.Fatalf("expected download link with max size to be rejected")
+	}
+}
@@ -1,10 +1,16 @@
 package runtime
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
-	"errors"
+	"encoding/hex"
 	"fmt"
 	"github.com/cloudimpl/polycode-sdk-go"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
 )
 
 type Folder struct {
@@ -12,6 +18,8 @@ type Folder struct {
 	sessionId string
 	parent    polycode.Folder
 	name      string
+	// temp is set for folders of the temp file store, whose files may expire
+	temp bool
 }
 
 func (f Folder) Parent() polycode.Folder {
@@ -23,6 +31,9 @@ func (f Folder) Name() string {
 }
 
 func (f Folder) Path() string {
+	if f.parent == nil {
+		return f.name
+	}
 	return f.parent.Path() + "/" + f.name
 }
 
@@ -32,12 +43,14 @@ func (f Folder) Folder(name string) polycode.Folder {
 		sessionId: f.sessionId,
 		parent:    f,
 		name:      name,
+		temp:      f.temp,
 	}
 }
 
 func (f Folder) CreateNewFolder(name string) (polycode.Folder, error) {
 	req := CreateFolderRequest{
 		Folder: name,
+		parent: f.Path(),
 	}
 
 	err := f.client.CreateFolder(f.sessionId, req)
@@ -55,6 +68,7 @@ func (f Folder) File(name string) polycode.File {
 		sessionId: f.sessionId,
 		parent:    f,
 		name:      name,
+		temp:      f.temp,
 	}
 }
 
@@ -63,6 +77,7 @@ type File struct {
 	sessionId string
 	parent    polycode.Folder
 	name      string
+	temp      bool
 }
 
 func (f File) Parent() polycode.Folder {
@@ -78,10 +93,12 @@ func (f File) Path() string {
 }
 
 func (f File) Get() (bool, []byte, error) {
-	req := GetFileRequest{
+	return f.get(GetFileRequest{
 		Key: f.Path(),
-	}
+	})
+}
 
+func (f File) get(req GetFileRequest) (bool, []byte, error) {
 	res, err := f.client.GetFile(f.sessionId, req)
 	if err != nil {
 		fmt.Printf("failed to get file: %s\n", err.Error())
@@ -102,36 +119,144 @@ func (f File) Get() (bool, []byte, error) {
 	return true, data, nil
 }
 
+// Download streams the file to filePath. The content is written to a temp file in the same
+// directory and renamed into place, so filePath is never left partially written.
 func (f File) Download(filePath string) error {
-	// new method added with v2
-	panic("implement me")
+	body, err := f.client.GetFileStream(f.sessionId, GetFileRequest{Key: f.Path()})
+	if err != nil {
+		fmt.Printf("failed to download file: %s\n", err.Error())
+		return err
+	}
+	defer body.Close()
+
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		fmt.Printf("failed to create temp file: %s\n", err.Error())
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = io.Copy(tmp, body); err != nil {
+		fmt.Printf("failed to download file: %s\n", err.Error())
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file owner-only, the downloaded file gets the usual permissions
+	if err = tmp.Chmod(0o644); err != nil {
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmpPath, filePath); err != nil {
+		fmt.Printf("failed to move downloaded file: %s\n", err.Error())
+		return err
+	}
+
+	committed = true
+	return nil
 }
 
-func (f File) GetDownloadLink() (string, error) {
-	req := GetFileRequest{
+// Stat returns the size, checksum, content type and metadata of the file
+func (f File) Stat() (FileInfo, error) {
+	req := StatFileRequest{
 		Key: f.Path(),
 	}
 
-	res, err := f.client.GetFileDownloadLink(f.sessionId, req)
+	res, err := f.client.StatFile(f.sessionId, req)
 	if err != nil {
-		fmt.Printf("failed to get file link: %s\n", err.Error())
-		return "", err
+		fmt.Printf("failed to stat file: %s\n", err.Error())
+		return FileInfo{}, err
+	}
+
+	if !res.Exists {
+		return FileInfo{}, ErrFileNotFound
 	}
 
-	if res.Link == "" {
-		return "", errors.New("empty link")
+	return FileInfo{
+		Name:           f.name,
+		Size:           res.Size,
+		ETag:           res.ETag,
+		ChecksumSha256: res.ChecksumSha256,
+		ContentType:    res.ContentType,
+		CacheControl:   res.CacheControl,
+		Metadata:       res.Metadata,
+		LastModified:   res.LastModified,
+	}, nil
+}
+
+func (f File) GetDownloadLink() (string, error) {
+	link, err := f.GetDownloadLinkWithOptions(LinkOptions{})
+	if err != nil {
+		return "", err
 	}
 
-	return res.Link, nil
+	return link.Url, nil
+}
+
+// FileAttributes are stored with the content of a file and returned by File.Stat
+type FileAttributes struct {
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+	// TTL after which a temp file is deleted, 0 uses the sidecar default. Only valid for temp files.
+	TTL time.Duration
+}
+
+// FileInfo describes a stored file without its content
+type FileInfo struct {
+	Name           string
+	Size           int64
+	ETag           string
+	ChecksumSha256 string
+	ContentType    string
+	CacheControl   string
+	Metadata       map[string]string
+	LastModified   time.Time
 }
 
 func (f File) Save(data []byte) error {
+	return f.SaveWithAttributes(data, FileAttributes{})
+}
+
+// SaveWithAttributes saves the file with the given content type, cache control and metadata.
+// The SHA-256 of data is sent along so the sidecar can reject a corrupted write.
+func (f File) SaveWithAttributes(data []byte, attributes FileAttributes) error {
+	if err := f.checkAttributes(attributes); err != nil {
+		return err
+	}
+
 	// Encode the data as base64
 	base64Data := base64.StdEncoding.EncodeToString(data)
+	checksum := sha256.Sum256(data)
 	req := PutFileRequest{
-		Key:      f.Path(),
-		TempFile: false,
-		Content:  base64Data,
+		Key:            f.Path(),
+		TempFile:       f.temp,
+		Content:        base64Data,
+		TTL:            attributes.TTL.Milliseconds(),
+		ContentType:    attributes.ContentType,
+		CacheControl:   attributes.CacheControl,
+		Metadata:       attributes.Metadata,
+		ChecksumSha256: hex.EncodeToString(checksum[:]),
 	}
 
 	err := f.client.PutFile(f.sessionId, req)
@@ -143,28 +268,38 @@ func (f File) Save(data []byte) error {
 	return nil
 }
 
+// Upload streams the local file at filePath to the file store
 func (f File) Upload(filePath string) error {
-	// new method added with v2
-	panic("implement me")
-}
+	file, err := os.Open(filePath)
+	if err != nil {
+		fmt.Printf("failed to open file: %s\n", err.Error())
+		return err
+	}
+	defer file.Close()
 
-func (f File) GetUploadLink() (string, error) {
-	req := GetUploadLinkRequest{
-		Key:      f.Path(),
-		TempFile: false,
+	req := PutFileRequest{
+		Key:         f.Path(),
+		TempFile:    f.temp,
+		FilePath:    filepath.Base(filePath),
+		ContentType: mime.TypeByExtension(filepath.Ext(filePath)),
 	}
 
-	res, err := f.client.GetFileUploadLink(f.sessionId, req)
+	err = f.client.PutFileStream(f.sessionId, req, file)
 	if err != nil {
-		fmt.Printf("failed to get file link: %s\n", err.Error())
-		return "", err
+		fmt.Printf("failed to upload file: %s\n", err.Error())
+		return err
 	}
 
-	if res.Link == "" {
-		return "", errors.New("empty link")
+	return nil
+}
+
+func (f File) GetUploadLink() (string, error) {
+	link, err := f.GetUploadLinkWithOptions(LinkOptions{})
+	if err != nil {
+		return "", err
 	}
 
-	return res.Link, nil
+	return link.Url, nil
 }
 
 func (f File) Delete() error {
@@ -183,8 +318,9 @@ func (f File) Delete() error {
 
 func (f File) Rename(newName string) error {
 	req := RenameFileRequest{
-		OldKey: f.Path(),
-		NewKey: f.parent.Path() + "/" + newName,
+		OldKey:   f.Path(),
+		NewKey:   f.parent.Path() + "/" + newName,
+		TempFile: f.temp,
 	}
 
 	err := f.client.RenameFile(f.sessionId, req)
@@ -199,8 +335,9 @@ func (f File) Rename(newName string) error {
 
 func (f File) MoveTo(dest polycode.Folder) error {
 	req := RenameFileRequest{
-		OldKey: f.Path(),
-		NewKey: dest.Path() + "/" + f.name,
+		OldKey:   f.Path(),
+		NewKey:   dest.Path() + "/" + f.name,
+		TempFile: isTempFolder(dest),
 	}
 
 	err := f.client.RenameFile(f.sessionId, req)
@@ -213,7 +350,19 @@ func (f File) MoveTo(dest polycode.Folder) error {
 	return nil
 }
 
+// CopyTo copies the file into dest on the file store side, the content never passes through the app
 func (f File) CopyTo(dest polycode.Folder) error {
-	// new method added with v2
-	panic("implement me")
+	req := CopyFileRequest{
+		SourceKey: f.Path(),
+		DestKey:   dest.Path() + "/" + f.name,
+		TempFile:  isTempFolder(dest),
+	}
+
+	err := f.client.CopyFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to copy file: %s\n", err.Error())
+		return err
+	}
+
+	return nil
 }
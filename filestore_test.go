@@ -0,0 +1,426 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ---- In-memory file store shared by the file tests ----
+
+// storedFile is one key of fileServiceClient, every write adds a version and a soft delete hides the key
+type storedFile struct {
+	versions [][]byte
+	put      PutFileRequest
+	deleted  bool
+}
+
+// fileServiceClient is an in-memory file store. Listings return one key per page to exercise paging,
+// and the last request of each kind is kept for assertions.
+type fileServiceClient struct {
+	*mockServiceClient
+	mu    sync.Mutex
+	files map[string]*storedFile
+
+	lists      int
+	copied     []string
+	put        PutFileRequest
+	promoted   PromoteFileRequest
+	versioning SetFolderVersioningRequest
+	download   GetDownloadLinkRequest
+	upload     GetUploadLinkRequest
+
+	// multipart uploads fail the first failures attempts of failPart
+	parts     map[int32]UploadedPart
+	uploads   map[int32]int
+	failPart  int32
+	failures  int
+	completed []UploadedPart
+}
+
+func newFileServiceClient(files map[string]string) *fileServiceClient {
+	c := &fileServiceClient{
+		mockServiceClient: &mockServiceClient{},
+		files:             map[string]*storedFile{},
+		parts:             map[int32]UploadedPart{},
+		uploads:           map[int32]int{},
+	}
+	for key, content := range files {
+		c.store(key, []byte(content))
+	}
+	return c
+}
+
+// store writes data as a new version of key
+func (c *fileServiceClient) store(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.storeLocked(PutFileRequest{Key: key}, data)
+}
+
+func (c *fileServiceClient) storeLocked(req PutFileRequest, data []byte) {
+	f, ok := c.files[req.Key]
+	if !ok {
+		f = &storedFile{}
+		c.files[req.Key] = f
+	}
+	f.versions = append(f.versions, data)
+	f.put = req
+	f.deleted = false
+}
+
+// latestLocked returns the current content of key, or false if it is missing or soft deleted
+func (c *fileServiceClient) latestLocked(key string) ([]byte, bool) {
+	f, ok := c.files[key]
+	if !ok || f.deleted || len(f.versions) == 0 {
+		return nil, false
+	}
+	return f.versions[len(f.versions)-1], true
+}
+
+// content returns the current content of key
+func (c *fileServiceClient) content(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, _ := c.latestLocked(key)
+	return string(data)
+}
+
+func (c *fileServiceClient) exists(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.latestLocked(key)
+	return ok
+}
+
+// keys returns the visible keys in sorted order
+func (c *fileServiceClient) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	for key := range c.files {
+		if _, ok := c.latestLocked(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *fileServiceClient) PutFile(sessionId string, req PutFileRequest) error {
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put = req
+	c.storeLocked(req, data)
+	return nil
+}
+
+func (c *fileServiceClient) PutFileStream(sessionId string, req PutFileRequest, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put = req
+	c.storeLocked(req, data)
+	return nil
+}
+
+func (c *fileServiceClient) GetFile(sessionId string, req GetFileRequest) (GetFileResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if req.VersionId == "" {
+		data, _ := c.latestLocked(req.Key)
+		return GetFileResponse{Content: base64.StdEncoding.EncodeToString(data)}, nil
+	}
+
+	if f, ok := c.files[req.Key]; ok {
+		for i, data := range f.versions {
+			if versionIdOf(i) == req.VersionId {
+				return GetFileResponse{Content: base64.StdEncoding.EncodeToString(data)}, nil
+			}
+		}
+	}
+	return GetFileResponse{}, nil
+}
+
+func (c *fileServiceClient) GetFileStream(sessionId string, req GetFileRequest) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.latestLocked(req.Key)
+	if !ok {
+		return nil, ErrFileNotFound.With(req.Key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *fileServiceClient) StatFile(sessionId string, req StatFileRequest) (StatFileResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.latestLocked(req.Key)
+	if !ok {
+		return StatFileResponse{}, nil
+	}
+
+	put := c.files[req.Key].put
+	return StatFileResponse{
+		Exists:         true,
+		Key:            req.Key,
+		Size:           int64(len(data)),
+		ChecksumSha256: put.ChecksumSha256,
+		ContentType:    put.ContentType,
+		Metadata:       put.Metadata,
+	}, nil
+}
+
+func (c *fileServiceClient) ListFile(sessionId string, req ListFilePageRequest) (ListFilePageResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lists++
+	var matched []string
+	for key := range c.files {
+		if _, ok := c.latestLocked(key); ok && strings.HasPrefix(key, req.Prefix) {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	start := 0
+	if req.ContinuationToken != nil {
+		start, _ = strconv.Atoi(*req.ContinuationToken)
+	}
+	if start >= len(matched) {
+		return ListFilePageResponse{}, nil
+	}
+
+	data, _ := c.latestLocked(matched[start])
+	res := ListFilePageResponse{Files: []ListFileResponse{{
+		Key:  strings.TrimPrefix(matched[start], req.Prefix),
+		Size: int64(len(data)),
+	}}}
+	if start+1 < len(matched) {
+		next := strconv.Itoa(start + 1)
+		res.NextContinuationToken = &next
+		res.IsTruncated = true
+	}
+	return res, nil
+}
+
+func (c *fileServiceClient) DeleteFile(sessionId string, req DeleteFileRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.files[req.Key]; ok && req.Soft {
+		f.deleted = true
+	} else {
+		delete(c.files, req.Key)
+	}
+	return nil
+}
+
+func (c *fileServiceClient) UndeleteFile(sessionId string, req UndeleteFileRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.files[req.Key]; ok {
+		f.deleted = false
+	}
+	return nil
+}
+
+func (c *fileServiceClient) CopyFile(sessionId string, req CopyFileRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, _ := c.latestLocked(req.SourceKey)
+	c.storeLocked(PutFileRequest{Key: req.DestKey}, data)
+	c.copied = append(c.copied, req.DestKey)
+	return nil
+}
+
+func (c *fileServiceClient) PromoteFile(sessionId string, req PromoteFileRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.promoted = req
+	if f, ok := c.files[req.Key]; ok {
+		c.files[req.DestKey] = f
+		delete(c.files, req.Key)
+	}
+	return nil
+}
+
+func (c *fileServiceClient) SetFolderVersioning(sessionId string, req SetFolderVersioningRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versioning = req
+	return nil
+}
+
+func (c *fileServiceClient) ListFileVersions(sessionId string, req ListFileVersionsRequest) (ListFileVersionsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res ListFileVersionsResponse
+	if f, ok := c.files[req.Key]; ok {
+		for i := len(f.versions) - 1; i >= 0; i-- {
+			res.Versions = append(res.Versions, FileVersion{
+				VersionId: versionIdOf(i),
+				Size:      int64(len(f.versions[i])),
+				IsLatest:  i == len(f.versions)-1,
+			})
+		}
+	}
+	return res, nil
+}
+
+func (c *fileServiceClient) RestoreFileVersion(sessionId string, req RestoreFileVersionRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := c.files[req.Key]; ok {
+		for i, data := range f.versions {
+			if versionIdOf(i) == req.VersionId {
+				f.versions = append(f.versions, data)
+				return nil
+			}
+		}
+	}
+	return ErrFileNotFound
+}
+
+func (c *fileServiceClient) GetFileDownloadLink(sessionId string, req GetDownloadLinkRequest) (GetLinkResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.download = req
+	return GetLinkResponse{Link: "http://mock.link/download", ExpiresAt: 1700000000000}, nil
+}
+
+func (c *fileServiceClient) GetFileUploadLink(sessionId string, req GetUploadLinkRequest) (GetLinkResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upload = req
+	return GetLinkResponse{Link: "http://mock.link/upload", Headers: map[string]string{"Content-Type": req.ContentType}}, nil
+}
+
+func (c *fileServiceClient) CreateMultipartUpload(string, CreateMultipartUploadRequest) (CreateMultipartUploadResponse, error) {
+	return CreateMultipartUploadResponse{UploadId: "upload-1"}, nil
+}
+
+func (c *fileServiceClient) PutFilePart(sessionId string, req PutFilePartRequest, body io.Reader) (UploadedPart, error) {
+	n, err := io.Copy(io.Discard, body)
+	if err != nil {
+		return UploadedPart{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads[req.PartNumber]++
+	if req.PartNumber == c.failPart && c.failures > 0 {
+		c.failures--
+		return UploadedPart{}, fmt.Errorf("connection reset")
+	}
+
+	part := UploadedPart{PartNumber: req.PartNumber, ETag: "etag", Size: n}
+	c.parts[req.PartNumber] = part
+	return part, nil
+}
+
+func (c *fileServiceClient) ListFileParts(string, ListFilePartsRequest) (ListFilePartsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res ListFilePartsResponse
+	for _, part := range c.parts {
+		res.Parts = append(res.Parts, part)
+	}
+	return res, nil
+}
+
+func (c *fileServiceClient) CompleteMultipartUpload(sessionId string, req CompleteMultipartUploadRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed = req.Parts
+	return nil
+}
+
+func versionIdOf(i int) string {
+	return "v" + strconv.Itoa(i+1)
+}
+
+// ---- Tests ----
+
+func TestFile_SaveWithAttributesThenStat(t *testing.T) {
+	client := newFileServiceClient(nil)
+	folder := Folder{client: client, sessionId: "s1", name: "files"}
+	f := folder.File("hello.txt").(File)
+
+	err := f.SaveWithAttributes([]byte("hello"), FileAttributes{
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"owner": "reports"},
+	})
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	// sha256("hello")
+	const want = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if client.put.ChecksumSha256 != want {
+		t.Fatalf("unexpected checksum %s", client.put.ChecksumSha256)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if info.Name != "hello.txt" || info.Size != 5 || info.ContentType != "text/plain" || info.Metadata["owner"] != "reports" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	if _, err := folder.File("missing.txt").(File).Stat(); err == nil {
+		t.Fatalf("expected stat of missing file to fail")
+	}
+}
+
+func TestFolder_CreateNewFolderSendsName(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/context/file/create-folder" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := Context{sessionId: "s1", client: NewServiceClient(server.URL)}
+	reports := ctx.FileStore().Folder("reports")
+	if _, err := reports.(Folder).CreateNewFolder("q3"); err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+
+	if len(got) != 1 || got["folder"] != "q3" {
+		t.Fatalf("expected only the folder name on the wire, got %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+)
+
+// StreamOptions controls a streamed file transfer
+type StreamOptions struct {
+	// FileAttributes are stored with the file by Create and ignored by Open
+	FileAttributes
+	// MaxSize fails the transfer once more than MaxSize bytes are read or written, 0 means unlimited
+	MaxSize int64
+	// Progress is called with the total number of bytes transferred so far
+	Progress func(transferred int64)
+}
+
+// Open streams the content of the file from the file store, the caller must close the returned reader
+func (f File) Open(options StreamOptions) (io.ReadCloser, error) {
+	return f.open(GetFileRequest{
+		Key: f.Path(),
+	}, options)
+}
+
+func (f File) open(req GetFileRequest, options StreamOptions) (io.ReadCloser, error) {
+	body, err := f.client.GetFileStream(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to open file: %s\n", err.Error())
+		return nil, err
+	}
+
+	return &streamReader{
+		reader:  body,
+		closer:  body,
+		options: options,
+	}, nil
+}
+
+// Create returns a writer that streams to the file, the file is saved once Close returns without error
+func (f File) Create(options StreamOptions) (io.WriteCloser, error) {
+	if err := f.checkAttributes(options.FileAttributes); err != nil {
+		return nil, err
+	}
+
+	req := PutFileRequest{
+		Key:          f.Path(),
+		TempFile:     f.temp,
+		TTL:          options.TTL.Milliseconds(),
+		ContentType:  options.ContentType,
+		CacheControl: options.CacheControl,
+		Metadata:     options.Metadata,
+	}
+
+	pr, pw := io.Pipe()
+	result := make(chan error, 1)
+	go func() {
+		err := f.client.PutFileStream(f.sessionId, req, pr)
+		// unblock the writer if the upload ended before the content was fully written
+		_ = pr.CloseWithError(err)
+		result <- err
+	}()
+
+	return &streamWriter{
+		writer:  pw,
+		result:  result,
+		options: options,
+	}, nil
+}
+
+type streamReader struct {
+	reader      io.Reader
+	closer      io.Closer
+	options     StreamOptions
+	transferred int64
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+		if r.options.MaxSize > 0 && r.transferred > r.options.MaxSize {
+			return 0, ErrFileTooLarge.With(r.options.MaxSize)
+		}
+
+		if r.options.Progress != nil {
+			r.options.Progress(r.transferred)
+		}
+	}
+	return n, err
+}
+
+func (r *streamReader) Close() error {
+	return r.closer.Close()
+}
+
+type streamWriter struct {
+	writer      *io.PipeWriter
+	result      chan error
+	options     StreamOptions
+	transferred int64
+	closed      bool
+	err         error
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.options.MaxSize > 0 && w.transferred+int64(len(p)) > w.options.MaxSize {
+		err := ErrFileTooLarge.With(w.options.MaxSize)
+		_ = w.writer.CloseWithError(err)
+		return 0, err
+	}
+
+	n, err := w.writer.Write(p)
+	w.transferred += int64(n)
+	if n > 0 && w.options.Progress != nil {
+		w.options.Progress(w.transferred)
+	}
+	return n, err
+}
+
+// Close ends the stream and waits for the file store to confirm the upload
+func (w *streamWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+
+	_ = w.writer.Close()
+	w.err = <-w.result
+	if w.err != nil {
+		fmt.Printf("failed to put file: %s\n", w.err.Error())
+	}
+	return w.err
+}
+
+// abortStream fails the upload of a writer returned by File.Create, so the partial content is not saved
+func abortStream(w io.WriteCloser, err error) {
+	if sw, ok := w.(*streamWriter); ok {
+		_ = sw.writer.CloseWithError(err)
+	}
+	_ = w.Close()
+}
@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFile_CreateThenOpen(t *testing.T) {
+	client := newFileServiceClient(nil)
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("report.txt").(File)
+
+	var progress int64
+	w, err := f.Create(StreamOptions{Progress: func(n int64) { progress = n }})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := io.Copy(w, strings.NewReader("hello world")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if progress != 11 {
+		t.Fatalf("expected progress 11, got %d", progress)
+	}
+
+	r, err := f.Open(StreamOptions{})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "hello world" {
+		t.Fatalf("unexpected content %q, err %v", data, err)
+	}
+}
+
+func TestFile_StreamEnforcesMaxSize(t *testing.T) {
+	client := newFileServiceClient(nil)
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("big.bin").(File)
+	client.store(f.Path(), bytes.Repeat([]byte("x"), 64))
+
+	r, err := f.Open(StreamOptions{MaxSize: 16})
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatalf("expected read past max size to fail")
+	}
+
+	w, err := f.Create(StreamOptions{MaxSize: 16})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 32)); err == nil {
+		t.Fatalf("expected write past max size to fail")
+	}
+	if err := w.Close(); err == nil {
+		t.Fatalf("expected close to report the aborted upload")
+	}
+}
+
+func TestFile_UploadThenDownload(t *testing.T) {
+	client := newFileServiceClient(nil)
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("report.txt").(File)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("from disk"), 0o644); err != nil {
+		t.Fatalf("write source failed: %v", err)
+	}
+
+	if err := f.Upload(src); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	dst := filepath.Join(dir, "dst.txt")
+	if err := f.Download(dst); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "from disk" {
+		t.Fatalf("unexpected content %q, err %v", data, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("expected no temp files to remain, found %d entries", len(entries))
+	}
+}
+
+func TestFile_DownloadMissingLeavesNoFile(t *testing.T) {
+	client := newFileServiceClient(nil)
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("missing.txt").(File)
+
+	dst := filepath.Join(t.TempDir(), "dst.txt")
+	if err := f.Download(dst); err == nil {
+		t.Fatalf("expected download of missing file to fail")
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected destination to not exist, got %v", err)
+	}
+}
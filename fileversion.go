@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// VersioningOptions controls versioning of the files below a folder
+type VersioningOptions struct {
+	// MaxVersions kept per file, older versions are removed as new ones are saved. 0 keeps every version.
+	MaxVersions int
+	// RestoreWindow is how long a soft deleted file stays restorable, 0 uses the sidecar default
+	RestoreWindow time.Duration
+}
+
+// EnableVersioning keeps earlier versions of the files below this folder when they are overwritten
+// or soft deleted. Files saved before versioning was enabled have no earlier versions.
+func (f Folder) EnableVersioning(options VersioningOptions) error {
+	if options.MaxVersions < 0 || options.RestoreWindow < 0 {
+		return fmt.Errorf("client: invalid versioning options %+v", options)
+	}
+
+	return f.setVersioning(SetFolderVersioningRequest{
+		Folder:        f.Path(),
+		Enabled:       true,
+		MaxVersions:   int32(options.MaxVersions),
+		RestoreWindow: options.RestoreWindow.Milliseconds(),
+	})
+}
+
+// DisableVersioning stops keeping new versions, versions already kept stay readable
+func (f Folder) DisableVersioning() error {
+	return f.setVersioning(SetFolderVersioningRequest{
+		Folder:  f.Path(),
+		Enabled: false,
+	})
+}
+
+func (f Folder) setVersioning(req SetFolderVersioningRequest) error {
+	err := f.client.SetFolderVersioning(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to set folder versioning: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Versions returns the stored versions of the file, newest first
+func (f File) Versions() ([]FileVersion, error) {
+	req := ListFileVersionsRequest{
+		Key: f.Path(),
+	}
+
+	res, err := f.client.ListFileVersions(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to list file versions: %s\n", err.Error())
+		return nil, err
+	}
+
+	return res.Versions, nil
+}
+
+// GetVersion reads the content of the given version, like Get it reports false if the version does not exist
+func (f File) GetVersion(versionId string) (bool, []byte, error) {
+	return f.get(GetFileRequest{
+		Key:       f.Path(),
+		VersionId: versionId,
+	})
+}
+
+// OpenVersion streams the content of the given version
+func (f File) OpenVersion(versionId string, options StreamOptions) (io.ReadCloser, error) {
+	return f.open(GetFileRequest{
+		Key:       f.Path(),
+		VersionId: versionId,
+	}, options)
+}
+
+// RestoreVersion makes the given version the latest version again, the versions after it are kept
+func (f File) RestoreVersion(versionId string) error {
+	req := RestoreFileVersionRequest{
+		Key:       f.Path(),
+		VersionId: versionId,
+	}
+
+	err := f.client.RestoreFileVersion(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to restore file version: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SoftDelete deletes the file so that it can still be restored with Undelete until the restore window
+// of its folder ends
+func (f File) SoftDelete() error {
+	req := DeleteFileRequest{
+		Key:  f.Path(),
+		Soft: true,
+	}
+
+	err := f.client.DeleteFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to delete file: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Undelete restores a soft deleted file to the version it had when it was deleted
+func (f File) Undelete() error {
+	req := UndeleteFileRequest{
+		Key: f.Path(),
+	}
+
+	err := f.client.UndeleteFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to undelete file: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
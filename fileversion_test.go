@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFile_VersionsRestoreAndSoftDelete(t *testing.T) {
+	client := newFileServiceClient(nil)
+	folder := Folder{client: client, sessionId: "s1", name: "files"}.Folder("contracts").(Folder)
+
+	if err := folder.EnableVersioning(VersioningOptions{MaxVersions: 10, RestoreWindow: 24 * time.Hour}); err != nil {
+		t.Fatalf("enable versioning failed: %v", err)
+	}
+	if client.versioning.Folder != "files/contracts" || client.versioning.RestoreWindow != 86400000 {
+		t.Fatalf("unexpected versioning request %+v", client.versioning)
+	}
+
+	f := folder.File("nda.txt").(File)
+	for _, content := range []string{"draft", "signed"} {
+		if err := f.Save([]byte(content)); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	versions, err := f.Versions()
+	if err != nil || len(versions) != 2 || !versions[0].IsLatest {
+		t.Fatalf("unexpected versions %+v, err %v", versions, err)
+	}
+
+	ok, data, err := f.GetVersion(versions[1].VersionId)
+	if err != nil || !ok || string(data) != "draft" {
+		t.Fatalf("unexpected first version %q, ok %v, err %v", data, ok, err)
+	}
+
+	if err := f.RestoreVersion(versions[1].VersionId); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, data, _ := f.Get(); string(data) != "draft" {
+		t.Fatalf("expected restored content, got %q", data)
+	}
+
+	if err := f.SoftDelete(); err != nil {
+		t.Fatalf("soft delete failed: %v", err)
+	}
+	if ok, _, _ := f.Get(); ok {
+		t.Fatalf("expected soft deleted file to be hidden")
+	}
+
+	if err := f.Undelete(); err != nil {
+		t.Fatalf("undelete failed: %v", err)
+	}
+	if _, data, _ := f.Get(); string(data) != "draft" {
+		t.Fatalf("expected undeleted content, got %q", data)
+	}
+}
@@ -0,0 +1,419 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultListPageSize is the number of keys requested per ListFile page
+const DefaultListPageSize int32 = 1000
+
+// DefaultFolderConcurrency bounds the number of file operations a recursive folder operation runs at once
+const DefaultFolderConcurrency = 8
+
+// SkipFolder can be returned by a WalkFunc to skip the contents of the folder it was called with
+var SkipFolder = errors.New("skip this folder")
+
+// FolderEntry is a file or a sub folder found by listing a folder
+type FolderEntry struct {
+	Name         string
+	IsFolder     bool
+	File         polycode.File
+	Folder       polycode.Folder
+	Size         int64
+	LastModified time.Time
+}
+
+// FolderIterator walks the direct children of a folder, fetching pages from the file store as it goes
+type FolderIterator struct {
+	folder  Folder
+	token   *string
+	started bool
+	page    []FolderEntry
+	current FolderEntry
+	seen    map[string]bool
+	err     error
+}
+
+// WalkFunc is called for each entry found by Folder.Walk, path is relative to the walked folder
+type WalkFunc func(path string, entry FolderEntry) error
+
+// List returns an iterator over the files and folders directly inside this folder
+func (f Folder) List() *FolderIterator {
+	return &FolderIterator{
+		folder: f,
+		seen:   make(map[string]bool),
+	}
+}
+
+// Next advances to the next entry, it returns false when the listing is exhausted or failed
+func (it *FolderIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.started && it.token == nil) {
+			return false
+		}
+
+		if !it.fetch() {
+			return false
+		}
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Entry returns the entry the iterator is positioned at
+func (it *FolderIterator) Entry() FolderEntry {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *FolderIterator) Err() error {
+	return it.err
+}
+
+func (it *FolderIterator) fetch() bool {
+	prefix := it.folder.Path() + "/"
+	res, err := it.folder.listPage(prefix, it.token)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.started = true
+	it.token = nextPageToken(res)
+
+	for _, file := range res.Files {
+		// keys are relative to the prefix, but tolerate a store that returns them in full
+		key := strings.TrimPrefix(file.Key, prefix)
+		if key == "" {
+			continue
+		}
+
+		// a nested key or a folder marker is reported once as the direct child folder holding it
+		if i := strings.Index(key, "/"); i >= 0 {
+			name := key[:i]
+			if it.seen[name] {
+				continue
+			}
+			it.seen[name] = true
+
+			it.page = append(it.page, FolderEntry{
+				Name:     name,
+				IsFolder: true,
+				Folder:   it.folder.Folder(name),
+			})
+			continue
+		}
+
+		it.page = append(it.page, FolderEntry{
+			Name:         key,
+			File:         it.folder.File(key),
+			Size:         file.Size,
+			LastModified: file.LastModified,
+		})
+	}
+
+	return true
+}
+
+// listPage fetches one page of the keys below prefix, the file store lists the whole subtree
+func (f Folder) listPage(prefix string, token *string) (ListFilePageResponse, error) {
+	req := ListFilePageRequest{
+		Prefix:            prefix,
+		MaxKeys:           DefaultListPageSize,
+		ContinuationToken: token,
+	}
+
+	res, err := f.client.ListFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to list files: %s\n", err.Error())
+		return ListFilePageResponse{}, err
+	}
+
+	return res, nil
+}
+
+func nextPageToken(res ListFilePageResponse) *string {
+	if res.IsTruncated && res.NextContinuationToken != nil && *res.NextContinuationToken != "" {
+		return res.NextContinuationToken
+	}
+	return nil
+}
+
+// Walk calls fn for every file and folder below this folder, visiting a folder before its contents.
+// The subtree is read with a single paged listing, folders are derived from the keys found in it.
+func (f Folder) Walk(fn WalkFunc) error {
+	prefix := f.Path() + "/"
+	folders := map[string]Folder{"": f}
+	var skipped []string
+
+	isSkipped := func(path string) bool {
+		for _, dir := range skipped {
+			if strings.HasPrefix(path, dir) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var token *string
+	for {
+		res, err := f.listPage(prefix, token)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range res.Files {
+			// keys are relative to the prefix, but tolerate a store that returns them in full
+			key := strings.TrimPrefix(file.Key, prefix)
+			if key == "" || isSkipped(key) {
+				continue
+			}
+
+			// report each folder on the way to the key the first time it is seen
+			parts := strings.Split(key, "/")
+			parent := ""
+			for _, name := range parts[:len(parts)-1] {
+				dir := name
+				if parent != "" {
+					dir = parent + "/" + name
+				}
+
+				if _, ok := folders[dir]; !ok {
+					folder := folders[parent].Folder(name).(Folder)
+					folders[dir] = folder
+
+					err = fn(dir, FolderEntry{
+						Name:     name,
+						IsFolder: true,
+						Folder:   folder,
+					})
+					if errors.Is(err, SkipFolder) {
+						skipped = append(skipped, dir+"/")
+						break
+					}
+					if err != nil {
+						return err
+					}
+				}
+				parent = dir
+			}
+
+			// a folder marker only reports its folder
+			name := parts[len(parts)-1]
+			if name == "" || isSkipped(key) {
+				continue
+			}
+
+			err = fn(key, FolderEntry{
+				Name:         name,
+				File:         folders[parent].File(name),
+				Size:         file.Size,
+				LastModified: file.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if token = nextPageToken(res); token == nil {
+			return nil
+		}
+	}
+}
+
+// Delete deletes every file below this folder, then the folder markers of it and its sub folders
+func (f Folder) Delete() error {
+	files, markers, err := f.subtree()
+	if err != nil {
+		return err
+	}
+
+	err = forEachKey(files, func(path string) error {
+		return f.deleteKey(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	return f.deleteMarkers(markers)
+}
+
+// CopyTo copies this folder and everything below it into dest, the copy is done on the file store side.
+// Empty folders are recreated in dest.
+func (f Folder) CopyTo(dest polycode.Folder) error {
+	files, markers, err := f.subtree()
+	if err != nil {
+		return err
+	}
+
+	if err = f.createMarkers(dest, markers); err != nil {
+		return err
+	}
+
+	target := dest.Path() + "/" + f.name
+	return forEachKey(files, func(path string) error {
+		req := CopyFileRequest{
+			SourceKey: f.Path() + "/" + path,
+			DestKey:   target + "/" + path,
+			TempFile:  isTempFolder(dest),
+		}
+
+		err := f.client.CopyFile(f.sessionId, req)
+		if err != nil {
+			fmt.Printf("failed to copy file: %s\n", err.Error())
+		}
+		return err
+	})
+}
+
+// MoveTo moves this folder and everything below it into dest, empty folders included
+func (f Folder) MoveTo(dest polycode.Folder) error {
+	files, markers, err := f.subtree()
+	if err != nil {
+		return err
+	}
+
+	if err = f.createMarkers(dest, markers); err != nil {
+		return err
+	}
+
+	target := dest.Path() + "/" + f.name
+	err = forEachKey(files, func(path string) error {
+		req := RenameFileRequest{
+			OldKey:   f.Path() + "/" + path,
+			NewKey:   target + "/" + path,
+			TempFile: isTempFolder(dest),
+		}
+
+		err := f.client.RenameFile(f.sessionId, req)
+		if err != nil {
+			fmt.Printf("failed to rename file: %s\n", err.Error())
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return f.deleteMarkers(markers)
+}
+
+// subtree lists every key below this folder relative to it, split into files and folder markers.
+// Markers end in a slash, the marker of this folder itself is the empty path.
+func (f Folder) subtree() (files []string, markers []string, err error) {
+	prefix := f.Path() + "/"
+
+	var token *string
+	for {
+		res, err := f.listPage(prefix, token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, file := range res.Files {
+			// keys are relative to the prefix, but tolerate a store that returns them in full
+			key := strings.TrimPrefix(file.Key, prefix)
+			if key == "" || strings.HasSuffix(key, "/") {
+				markers = append(markers, key)
+			} else {
+				files = append(files, key)
+			}
+		}
+
+		if token = nextPageToken(res); token == nil {
+			return files, markers, nil
+		}
+	}
+}
+
+func (f Folder) deleteKey(path string) error {
+	req := DeleteFileRequest{
+		Key: f.Path() + "/" + path,
+	}
+
+	err := f.client.DeleteFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to delete file: %s\n", err.Error())
+	}
+	return err
+}
+
+// deleteMarkers deletes the given folder markers, sub folders before the folders holding them
+func (f Folder) deleteMarkers(markers []string) error {
+	sort.Sort(sort.Reverse(sort.StringSlice(markers)))
+	for _, marker := range markers {
+		if err := f.deleteKey(marker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createMarkers creates the folders of the given markers below dest, parents before their sub folders
+func (f Folder) createMarkers(dest polycode.Folder, markers []string) error {
+	sort.Strings(markers)
+	for _, marker := range markers {
+		parent, name := dest, f.name
+		if marker != "" {
+			parent = dest.Folder(f.name)
+			parts := strings.Split(strings.TrimSuffix(marker, "/"), "/")
+			for _, part := range parts[:len(parts)-1] {
+				parent = parent.Folder(part)
+			}
+			name = parts[len(parts)-1]
+		}
+
+		if _, err := parent.CreateNewFolder(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachKey runs op on every path with at most DefaultFolderConcurrency in flight.
+// Once an operation fails no new ones are started and the first error is returned.
+func forEachKey(paths []string, op func(path string) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	sem := make(chan struct{}, DefaultFolderConcurrency)
+	for _, path := range paths {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(path string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := op(path); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(path)
+	}
+	wg.Wait()
+
+	return firstErr
+}
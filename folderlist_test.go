@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFolder_ListFollowsContinuationTokens(t *testing.T) {
+	client := newFileServiceClient(map[string]string{
+		"files/a.txt":     "",
+		"files/b.txt":     "",
+		"files/sub/c.txt": "",
+		"files/sub/d.txt": "",
+	})
+	folder := Folder{client: client, sessionId: "s1", name: "files"}
+
+	var names []string
+	it := folder.List()
+	for it.Next() {
+		entry := it.Entry()
+		if entry.IsFolder {
+			names = append(names, entry.Name+"/")
+		} else {
+			names = append(names, entry.Name)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+
+	if strings.Join(names, ",") != "a.txt,b.txt,sub/" {
+		t.Fatalf("unexpected entries %v", names)
+	}
+}
+
+func TestFolder_WalkAndRecursiveOperations(t *testing.T) {
+	client := newFileServiceClient(map[string]string{
+		"files/a.txt":          "",
+		"files/sub/b.txt":      "",
+		"files/sub/deep/c.txt": "",
+		"files/skip/d.txt":     "",
+	})
+	folder := Folder{client: client, sessionId: "s1", name: "files"}
+
+	var paths []string
+	err := folder.Walk(func(path string, entry FolderEntry) error {
+		paths = append(paths, path)
+		if entry.IsFolder && entry.Name == "skip" {
+			return SkipFolder
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if strings.Join(paths, ",") != "a.txt,skip,sub,sub/b.txt,sub/deep,sub/deep/c.txt" {
+		t.Fatalf("unexpected walk order %v", paths)
+	}
+
+	// one page per key of a single listing, sub folders are not listed again
+	if client.lists != 4 {
+		t.Fatalf("expected 4 list calls, got %d", client.lists)
+	}
+
+	dest := Folder{client: client, sessionId: "s1", name: "backup"}
+	if err := folder.Folder("sub").(Folder).CopyTo(dest); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	sort.Strings(client.copied)
+	if strings.Join(client.copied, ",") != "backup/sub/b.txt,backup/sub/deep/c.txt" {
+		t.Fatalf("unexpected copies %v", client.copied)
+	}
+
+	if err := folder.Delete(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	for _, key := range client.keys() {
+		if strings.HasPrefix(key, "files/") {
+			t.Fatalf("expected %s to be deleted", key)
+		}
+	}
+}
+
+func TestFolder_RecursiveOperationsKeepEmptyFolders(t *testing.T) {
+	store, root := newTestLocalFileStore(t)
+
+	// reports/q3.txt, reports/drafts/ and reports/archive/2023/ with the last two empty
+	reports, err := root.CreateNewFolder("reports")
+	if err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	if err := reports.File("q3.txt").(File).Save([]byte("revenue")); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if _, err := reports.CreateNewFolder("drafts"); err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	archive, err := reports.CreateNewFolder("archive")
+	if err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	if _, err := archive.CreateNewFolder("2023"); err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+
+	exists := func(key string) bool {
+		_, err := os.Stat(filepath.Join(store.dir, filepath.FromSlash(key)))
+		return err == nil
+	}
+
+	backup, err := root.CreateNewFolder("backup")
+	if err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	if err := reports.(Folder).CopyTo(backup); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	for _, key := range []string{"files/backup/reports/q3.txt", "files/backup/reports/drafts", "files/backup/reports/archive/2023"} {
+		if !exists(key) {
+			t.Fatalf("expected %s to be copied", key)
+		}
+	}
+
+	moved, err := root.CreateNewFolder("moved")
+	if err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	if err := backup.Folder("reports").(Folder).MoveTo(moved); err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	for _, key := range []string{"files/moved/reports/q3.txt", "files/moved/reports/drafts", "files/moved/reports/archive/2023"} {
+		if !exists(key) {
+			t.Fatalf("expected %s to be moved", key)
+		}
+	}
+	if exists("files/backup/reports") {
+		t.Fatalf("expected the moved folder to be removed from its source")
+	}
+
+	// an emptied folder is not listed back as a folder marker
+	if err := reports.(Folder).Delete(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if exists("files/reports") {
+		t.Fatalf("expected the folder and its empty sub folders to be deleted")
+	}
+}
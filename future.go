@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+const (
+	WaitForAll BatchWaitMode = "all"
+	WaitForAny BatchWaitMode = "any"
+)
+
+type BatchWaitMode string
+
+// FutureGroup collects child service and agent calls so they can be started together
+// and awaited as a set. Calls are sent to the sidecar in the order they were added,
+// which keeps the batch deterministic across re-executions of the workflow.
+type FutureGroup struct {
+	ctx           context.Context
+	sessionId     string
+	serviceClient ServiceClient
+	batchId       string
+	requests      []ExecServiceRequest
+	responses     []*Response
+}
+
+type Future struct {
+	group *FutureGroup
+	index int
+}
+
+// Service adds a call to the given service method to the group. Calls can only be added
+// before the group is first awaited; afterwards ErrFutureGroupStarted is returned.
+func (g *FutureGroup) Service(options polycode.TaskOptions, service string, method string, input any) (Future, error) {
+	return g.ServiceEx(options, "", service, method, input)
+}
+
+// ServiceEx adds a call to the given service method in another environment to the group
+func (g *FutureGroup) ServiceEx(options polycode.TaskOptions, envId string, service string, method string, input any) (Future, error) {
+	return g.add(ExecServiceRequest{
+		EnvId:   envId,
+		Service: service,
+		Method:  method,
+		Options: options,
+		Input:   input,
+	})
+}
+
+// Agent adds an agent call to the group
+func (g *FutureGroup) Agent(options polycode.TaskOptions, agent string, input polycode.AgentInput) (Future, error) {
+	return g.AgentEx(options, "", agent, input)
+}
+
+// AgentEx adds a call to an agent in another environment to the group
+func (g *FutureGroup) AgentEx(options polycode.TaskOptions, envId string, agent string, input polycode.AgentInput) (Future, error) {
+	return g.add(ExecServiceRequest{
+		EnvId:   envId,
+		Service: "agent-service",
+		Method:  "CallAgent",
+		Options: options.WithSequenceKey(agent + ":" + input.SessionKey),
+		Headers: map[string]string{
+			AgentNameHeader: agent,
+		},
+		Input: input,
+	})
+}
+
+func (g *FutureGroup) add(req ExecServiceRequest) (Future, error) {
+	if g.batchId != "" {
+		return Future{}, ErrFutureGroupStarted
+	}
+
+	g.requests = append(g.requests, req)
+	g.responses = append(g.responses, nil)
+	return Future{
+		group: g,
+		index: len(g.requests) - 1,
+	}, nil
+}
+
+// AwaitAll starts any calls that are not yet started and suspends the workflow until all of them complete
+func (g *FutureGroup) AwaitAll() error {
+	if _, err := g.await(WaitForAll); err != nil {
+		return err
+	}
+
+	for i, res := range g.responses {
+		if res == nil {
+			return ErrTaskExecError.Wrap(fmt.Errorf("batch call %d still pending after waiting for all", i))
+		}
+	}
+	return nil
+}
+
+// AwaitAny starts any calls that are not yet started and suspends the workflow until at least one of them
+// completes. It returns the future that satisfied the wait, as recorded by the sidecar.
+func (g *FutureGroup) AwaitAny() (Future, error) {
+	index, err := g.await(WaitForAny)
+	if err != nil {
+		return Future{}, err
+	}
+
+	if index < 0 || index >= len(g.requests) || g.responses[index] == nil {
+		return Future{}, ErrTaskExecError.Wrap(fmt.Errorf("batch completed index %d is not a completed call", index))
+	}
+
+	return Future{
+		group: g,
+		index: index,
+	}, nil
+}
+
+func (g *FutureGroup) await(mode BatchWaitMode) (int, error) {
+	if len(g.requests) == 0 {
+		return -1, ErrFutureGroupEmpty
+	}
+
+	req := ExecServiceBatchRequest{
+		BatchId:  g.batchId,
+		Requests: g.requests,
+		WaitFor:  mode,
+	}
+
+	output, err := g.serviceClient.ExecServiceBatch(g.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: exec task batch error: %v\n", err)
+		return -1, ErrTaskExecError.Wrap(err)
+	}
+
+	if len(output.Responses) != len(g.requests) {
+		return -1, ErrTaskExecError.Wrap(fmt.Errorf("batch response size mismatch, expected %d got %d",
+			len(g.requests), len(output.Responses)))
+	}
+
+	fmt.Printf("client: exec task batch %s output: %v\n", output.BatchId, output)
+	g.batchId = output.BatchId
+	for i, res := range output.Responses {
+		if res.IsAsync {
+			continue
+		}
+
+		g.responses[i] = &Response{
+			output:  res.Output,
+			isError: res.IsError,
+			error:   res.Error,
+		}
+	}
+
+	return output.CompletedIndex, nil
+}
+
+// IsDone reports whether the call completed in the last await of its group
+func (f Future) IsDone() bool {
+	return f.group.responses[f.index] != nil
+}
+
+// Get returns the result of the call, or an error response if the call is still pending
+func (f Future) Get() polycode.Response {
+	res := f.group.responses[f.index]
+	if res == nil {
+		return Response{
+			output:  nil,
+			isError: true,
+			error:   ErrFuturePending,
+		}
+	}
+
+	return *res
+}
@@ -0,0 +1,175 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+type batchServiceClient struct {
+	*mockServiceClient
+	gotReq ExecServiceBatchRequest
+	out    ExecServiceBatchResponse
+}
+
+func (m *batchServiceClient) ExecServiceBatch(sessionId string, req ExecServiceBatchRequest) (ExecServiceBatchResponse, error) {
+	m.gotReq = req
+	return m.out, nil
+}
+
+func TestFutureGroup_AwaitAllKeepsOrder(t *testing.T) {
+	mock := &batchServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out: ExecServiceBatchResponse{
+			BatchId: "batch-1",
+			Responses: []ExecServiceResponse{
+				{Output: "paid"},
+				{Output: "reserved"},
+			},
+		},
+	}
+
+	g := &FutureGroup{
+		ctx:           context.Background(),
+		sessionId:     "sess-1",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	f1, _ := g.Service(opts, "payment", "Charge", 10)
+	f2, _ := g.Service(opts, "inventory", "Reserve", 20)
+
+	if err := g.AwaitAll(); err != nil {
+		t.Fatalf("AwaitAll failed: %v", err)
+	}
+
+	if len(mock.gotReq.Requests) != 2 || mock.gotReq.Requests[0].Service != "payment" || mock.gotReq.Requests[1].Service != "inventory" {
+		t.Fatalf("batch requests out of order: %+v", mock.gotReq.Requests)
+	}
+	if mock.gotReq.WaitFor != WaitForAll {
+		t.Fatalf("expected wait mode %q, got %q", WaitForAll, mock.gotReq.WaitFor)
+	}
+
+	var out string
+	if err := f1.Get().Get(&out); err != nil || out != "paid" {
+		t.Fatalf("unexpected first result %q (err=%v)", out, err)
+	}
+	if err := f2.Get().Get(&out); err != nil || out != "reserved" {
+		t.Fatalf("unexpected second result %q (err=%v)", out, err)
+	}
+}
+
+func TestFutureGroup_AwaitAnyLeavesOthersPending(t *testing.T) {
+	mock := &batchServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out: ExecServiceBatchResponse{
+			BatchId: "batch-2",
+			Responses: []ExecServiceResponse{
+				{IsAsync: true},
+				{Output: "fast"},
+			},
+			CompletedIndex: 1,
+		},
+	}
+
+	g := &FutureGroup{
+		ctx:           context.Background(),
+		sessionId:     "sess-2",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	slow, _ := g.Service(opts, "slow", "Run", nil)
+	g.Service(opts, "fast", "Run", nil)
+
+	winner, err := g.AwaitAny()
+	if err != nil {
+		t.Fatalf("AwaitAny failed: %v", err)
+	}
+
+	if winner.index != 1 || !winner.IsDone() {
+		t.Fatalf("unexpected winner %+v", winner)
+	}
+	if slow.IsDone() || !slow.Get().IsError() {
+		t.Fatalf("expected slow future to be pending")
+	}
+	if g.batchId != "batch-2" {
+		t.Fatalf("batch id not recorded, got %q", g.batchId)
+	}
+}
+
+func TestFutureGroup_AddAfterAwaitFails(t *testing.T) {
+	mock := &batchServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out: ExecServiceBatchResponse{
+			BatchId: "batch-3",
+			Responses: []ExecServiceResponse{
+				{Output: "done"},
+			},
+		},
+	}
+
+	g := &FutureGroup{
+		ctx:           context.Background(),
+		sessionId:     "sess-3",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	if _, err := g.AgentEx(opts, "env-2", "planner", polycode.AgentInput{SessionKey: "s"}); err != nil {
+		t.Fatalf("unexpected add error: %v", err)
+	}
+	if _, err := g.AwaitAny(); err != nil {
+		t.Fatalf("AwaitAny failed: %v", err)
+	}
+
+	req := mock.gotReq.Requests[0]
+	if req.EnvId != "env-2" || req.Service != "agent-service" || req.Headers[AgentNameHeader] != "planner" {
+		t.Fatalf("unexpected agent request %+v", req)
+	}
+
+	if _, err := g.Service(opts, "late", "Run", nil); err == nil {
+		t.Fatalf("expected adding to a started group to fail")
+	}
+	if len(g.requests) != 1 {
+		t.Fatalf("late call must not join the started batch, got %d requests", len(g.requests))
+	}
+}
+
+func TestFutureGroup_RejectsInconsistentBatchResponse(t *testing.T) {
+	mock := &batchServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out: ExecServiceBatchResponse{
+			BatchId: "batch-3",
+			Responses: []ExecServiceResponse{
+				{IsAsync: true},
+				{Output: "done"},
+			},
+			CompletedIndex: 2,
+		},
+	}
+
+	g := &FutureGroup{
+		ctx:           context.Background(),
+		sessionId:     "sess-3",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	g.Service(opts, "a", "Run", nil)
+	g.Service(opts, "b", "Run", nil)
+
+	if _, err := g.AwaitAny(); err == nil {
+		t.Fatalf("expected an out of range completed index to fail")
+	}
+
+	mock.out.CompletedIndex = 0
+	if _, err := g.AwaitAny(); err == nil {
+		t.Fatalf("expected a completed index of a pending call to fail")
+	}
+
+	if err := g.AwaitAll(); err == nil {
+		t.Fatalf("expected wait for all to fail while a call is still pending")
+	}
+}
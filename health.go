@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HealthStatusOk   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// DefaultHealthCheckTimeout bounds every health check run for a readiness probe
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// Health holds the startup state and the readiness checks of this app
+var Health = NewHealthRegistry()
+
+// HealthCheck reports an error if the dependency it checks is not usable
+type HealthCheck func(ctx context.Context) error
+
+type HealthCheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration"`
+}
+
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+type HealthRegistry struct {
+	mu      sync.Mutex
+	checks  map[string]HealthCheck
+	started atomic.Bool
+}
+
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{
+		checks: make(map[string]HealthCheck),
+	}
+}
+
+// Register adds a named readiness check
+func (h *HealthRegistry) Register(name string, check HealthCheck) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.checks[name]; ok {
+		return fmt.Errorf("client: health check %s already registered", name)
+	}
+
+	h.checks[name] = check
+	return nil
+}
+
+// MarkStarted records that the app completed its handshake with the sidecar
+func (h *HealthRegistry) MarkStarted() {
+	h.started.Store(true)
+}
+
+func (h *HealthRegistry) IsStarted() bool {
+	return h.started.Load()
+}
+
+// Check runs the handshake check and every registered check concurrently and reports the result of each
+func (h *HealthRegistry) Check(ctx context.Context) HealthReport {
+	h.mu.Lock()
+	checks := make(map[string]HealthCheck, len(h.checks)+1)
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.Unlock()
+
+	checks["handshake"] = func(ctx context.Context) error {
+		if !h.IsStarted() {
+			return errors.New("app not registered with sidecar")
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]HealthCheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, check)
+		}(i, checks[name])
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status: HealthStatusOk,
+		Checks: make(map[string]HealthCheckResult, len(names)),
+	}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != HealthStatusOk {
+			report.Status = HealthStatusFail
+		}
+	}
+
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) (result HealthCheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start).Milliseconds()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		return HealthCheckResult{
+			Status: HealthStatusFail,
+			Error:  err.Error(),
+		}
+	}
+
+	return HealthCheckResult{
+		Status: HealthStatusOk,
+	}
+}
+
+func RegisterHealthCheck(name string, check HealthCheck) error {
+	return Health.Register(name, check)
+}
@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"github.com/cloudimpl/polycode-sdk-go"
+	"net/http"
+	"sync"
+)
+
+// ServiceInvocation is what a ServiceInterceptor sees of a service or workflow invocation
+type ServiceInvocation struct {
+	Event      ServiceStartEvent
+	Context    *Context
+	Input      any
+	IsWorkflow bool
+}
+
+type ServiceHandler func(inv ServiceInvocation) (any, error)
+
+// ServiceInterceptor wraps a service invocation. It may inspect or replace the input before calling
+// next, inspect the result after it, or return an error without calling next to short-circuit.
+type ServiceInterceptor func(inv ServiceInvocation, next ServiceHandler) (any, error)
+
+// ApiInvocation is what an ApiInterceptor sees of an api invocation
+type ApiInvocation struct {
+	Event   ApiStartEvent
+	Context *Context
+	Request *http.Request
+}
+
+type ApiHandler func(inv ApiInvocation) (polycode.ApiResponse, error)
+
+// ApiInterceptor wraps an api invocation. It may return its own response without calling next,
+// for example to reject unauthenticated requests.
+type ApiInterceptor func(inv ApiInvocation, next ApiHandler) (polycode.ApiResponse, error)
+
+// interceptorChain holds the registered interceptors, the first registered runs outermost.
+// A nil chain has no interceptors and runs the handler directly.
+type interceptorChain struct {
+	mu      sync.RWMutex
+	service []ServiceInterceptor
+	api     []ApiInterceptor
+}
+
+func (ic *interceptorChain) addService(interceptor ServiceInterceptor) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.service = append(ic.service, interceptor)
+}
+
+func (ic *interceptorChain) addApi(interceptor ApiInterceptor) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.api = append(ic.api, interceptor)
+}
+
+func (ic *interceptorChain) runService(inv ServiceInvocation, handler ServiceHandler) (any, error) {
+	if ic == nil {
+		return handler(inv)
+	}
+
+	ic.mu.RLock()
+	interceptors := ic.service
+	ic.mu.RUnlock()
+
+	next := handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(inv ServiceInvocation) (any, error) {
+			return interceptor(inv, inner)
+		}
+	}
+
+	return next(inv)
+}
+
+func (ic *interceptorChain) runApi(inv ApiInvocation, handler ApiHandler) (polycode.ApiResponse, error) {
+	if ic == nil {
+		return handler(inv)
+	}
+
+	ic.mu.RLock()
+	interceptors := ic.api
+	ic.mu.RUnlock()
+
+	next := handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(inv ApiInvocation) (polycode.ApiResponse, error) {
+			return interceptor(inv, inner)
+		}
+	}
+
+	return next(inv)
+}
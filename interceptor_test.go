@@ -0,0 +1,77 @@
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+func TestInterceptorChain_OrderAndShortCircuit(t *testing.T) {
+	chain := &interceptorChain{}
+
+	var calls []string
+	chain.addService(func(inv ServiceInvocation, next ServiceHandler) (any, error) {
+		calls = append(calls, "audit:before")
+		ret, err := next(inv)
+		calls = append(calls, "audit:after")
+		return ret, err
+	})
+	chain.addService(func(inv ServiceInvocation, next ServiceHandler) (any, error) {
+		if inv.Event.Method == "Forbidden" {
+			return nil, errors.New("access denied")
+		}
+		inv.Input = "redacted"
+		return next(inv)
+	})
+
+	handler := func(inv ServiceInvocation) (any, error) {
+		calls = append(calls, "handler")
+		return inv.Input, nil
+	}
+
+	ret, err := chain.runService(ServiceInvocation{Event: ServiceStartEvent{Method: "Allowed"}, Input: "secret"}, handler)
+	if err != nil || ret != "redacted" {
+		t.Fatalf("unexpected result %v (err=%v)", ret, err)
+	}
+
+	want := []string{"audit:before", "handler", "audit:after"}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] || calls[2] != want[2] {
+		t.Fatalf("unexpected call order %v", calls)
+	}
+
+	calls = nil
+	_, err = chain.runService(ServiceInvocation{Event: ServiceStartEvent{Method: "Forbidden"}}, handler)
+	if err == nil {
+		t.Fatalf("expected short-circuit error")
+	}
+	for _, c := range calls {
+		if c == "handler" {
+			t.Fatalf("handler must not run after short-circuit")
+		}
+	}
+}
+
+func TestInterceptorChain_NilRunsHandler(t *testing.T) {
+	var chain *interceptorChain
+
+	ret, err := chain.runService(ServiceInvocation{Input: "input"}, func(inv ServiceInvocation) (any, error) {
+		return inv.Input, nil
+	})
+	if err != nil || ret != "input" {
+		t.Fatalf("unexpected result %v (err=%v)", ret, err)
+	}
+
+	res, err := chain.runApi(ApiInvocation{}, func(inv ApiInvocation) (polycode.ApiResponse, error) {
+		return polycode.ApiResponse{StatusCode: 204}, nil
+	})
+	if err != nil || res.StatusCode != 204 {
+		t.Fatalf("unexpected response %+v (err=%v)", res, err)
+	}
+
+	if err = (ClientRuntime{}).RegisterServiceInterceptor(func(inv ServiceInvocation, next ServiceHandler) (any, error) {
+		return next(inv)
+	}); err == nil {
+		t.Fatalf("expected registering on a runtime without a chain to fail")
+	}
+}
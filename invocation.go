@@ -0,0 +1,130 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Invocations tracks the running service and api invocations of this app
+var Invocations = NewInvocationRegistry()
+
+type invocation struct {
+	ctx      context.Context
+	detached context.Context
+	cancel   context.CancelCauseFunc
+	detaches int
+}
+
+// InvocationRegistry keeps the context of every running invocation keyed by session id so it can be
+// cancelled by the platform. Sidecar calls made on behalf of the session are bound to the same context,
+// so cancelling an invocation also aborts its calls in flight. Work that must reach the sidecar after a
+// cancel, like compensations, runs under Detach.
+type InvocationRegistry struct {
+	mu          sync.Mutex
+	invocations map[string]*invocation
+}
+
+func NewInvocationRegistry() *InvocationRegistry {
+	return &InvocationRegistry{
+		invocations: make(map[string]*invocation),
+	}
+}
+
+// Register derives a cancellable context for the session to hand to the handler, the returned release
+// func must be called when the invocation completes
+func (r *InvocationRegistry) Register(parent context.Context, sessionId string) (context.Context, func()) {
+	detached, stop := context.WithCancel(parent)
+	ctx, cancel := context.WithCancelCause(detached)
+
+	inv := &invocation{
+		ctx:      ctx,
+		detached: detached,
+		cancel:   cancel,
+	}
+
+	r.mu.Lock()
+	r.invocations[sessionId] = inv
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		if r.invocations[sessionId] == inv {
+			delete(r.invocations, sessionId)
+		}
+		r.mu.Unlock()
+		cancel(nil)
+		stop()
+	}
+}
+
+// Cancel cancels the context of the session and the sidecar calls bound to it, and reports whether it
+// was running
+func (r *InvocationRegistry) Cancel(sessionId string, reason string) bool {
+	r.mu.Lock()
+	inv, ok := r.invocations[sessionId]
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	inv.cancel(errors.New(reason))
+	return true
+}
+
+// CancelAll cancels every running invocation
+func (r *InvocationRegistry) CancelAll(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, inv := range r.invocations {
+		inv.cancel(errors.New(reason))
+	}
+}
+
+// Detach binds the sidecar calls of the session to a context that is not cancelled with the invocation
+// until the returned func is called. The calls still end with the deadline of the invocation or once it
+// completes.
+func (r *InvocationRegistry) Detach(sessionId string) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invocations[sessionId]
+	if !ok {
+		return func() {}
+	}
+
+	inv.detaches++
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		inv.detaches--
+	}
+}
+
+// Context returns the context sidecar calls of the session are bound to, or context.Background if the
+// session has no running invocation
+func (r *InvocationRegistry) Context(sessionId string) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invocations[sessionId]
+	if !ok {
+		return context.Background()
+	}
+
+	if inv.detaches > 0 {
+		return inv.detached
+	}
+	return inv.ctx
+}
+
+// Count returns the number of running invocations
+func (r *InvocationRegistry) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.invocations)
+}
@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ApiLimitKey is the limit key applied to api invocations
+const ApiLimitKey = "api"
+
+// ConcurrencyConfig bounds the invocations the app runs at once. Limits are keyed by service name,
+// "service.method" or ApiLimitKey, a zero limit means unlimited.
+type ConcurrencyConfig struct {
+	MaxConcurrent int            `json:"maxConcurrent"`
+	MaxQueued     int            `json:"maxQueued"`
+	Limits        map[string]int `json:"limits"`
+	// QueueTimeout bounds the time in milliseconds an invocation waits in the queue, 0 waits until
+	// the caller gives up
+	QueueTimeout int64 `json:"queueTimeout"`
+}
+
+var errQueueFull = errors.New("invocation queue is full")
+var errQueueTimeout = errors.New("invocation queue wait timed out")
+
+type ConcurrencyStats struct {
+	InFlight int64 `json:"inFlight"`
+	Queued   int64 `json:"queued"`
+}
+
+type semaphore chan struct{}
+
+// ConcurrencyLimiter admits invocations up to the configured limits and queues the rest. Once
+// MaxQueued invocations are waiting, further invocations are rejected, and a queued invocation
+// is rejected once it waited QueueTimeout.
+type ConcurrencyLimiter struct {
+	maxQueued    int64
+	queueTimeout time.Duration
+	global       semaphore
+	limits       map[string]semaphore
+	inFlight     atomic.Int64
+	queued       atomic.Int64
+}
+
+func NewConcurrencyLimiter(config ConcurrencyConfig) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		maxQueued:    int64(config.MaxQueued),
+		queueTimeout: time.Duration(config.QueueTimeout) * time.Millisecond,
+		limits:       make(map[string]semaphore),
+	}
+
+	if config.MaxConcurrent > 0 {
+		l.global = make(semaphore, config.MaxConcurrent)
+	}
+
+	for key, limit := range config.Limits {
+		if limit > 0 {
+			l.limits[key] = make(semaphore, limit)
+		}
+	}
+
+	return l
+}
+
+// Acquire waits for a slot under every limit matching the keys and the global limit. The returned
+// release func must be called once the invocation completes.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, keys ...string) (func(), error) {
+	// keyed limits are always taken before the global limit so waiters cannot deadlock each other
+	var sems []semaphore
+	for _, key := range keys {
+		if sem, ok := l.limits[key]; ok {
+			sems = append(sems, sem)
+		}
+	}
+	if l.global != nil {
+		sems = append(sems, l.global)
+	}
+
+	acquired := make([]semaphore, 0, len(sems))
+	releaseAll := func() {
+		for _, sem := range acquired {
+			<-sem
+		}
+	}
+
+	// the queue timeout covers the whole wait, across every limit the invocation queues on
+	var timeout <-chan time.Time
+	isQueued := false
+	dequeue := func() {
+		if isQueued {
+			l.queued.Add(-1)
+			isQueued = false
+		}
+	}
+
+	for _, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+			acquired = append(acquired, sem)
+			continue
+		default:
+		}
+
+		if !isQueued {
+			if l.queued.Add(1) > l.maxQueued {
+				l.queued.Add(-1)
+				releaseAll()
+				return nil, errQueueFull
+			}
+			isQueued = true
+
+			if l.queueTimeout > 0 {
+				timer := time.NewTimer(l.queueTimeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		}
+
+		select {
+		case sem <- struct{}{}:
+			acquired = append(acquired, sem)
+		case <-ctx.Done():
+			dequeue()
+			releaseAll()
+			return nil, ctx.Err()
+		case <-timeout:
+			dequeue()
+			releaseAll()
+			return nil, errQueueTimeout
+		}
+	}
+
+	dequeue()
+	l.inFlight.Add(1)
+	return func() {
+		l.inFlight.Add(-1)
+		releaseAll()
+	}, nil
+}
+
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: l.inFlight.Load(),
+		Queued:   l.queued.Load(),
+	}
+}
@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter_RejectsWhenQueueFull(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{MaxConcurrent: 1, MaxQueued: 0})
+
+	release, err := l.Acquire(context.Background(), "orders")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	if _, err := l.Acquire(context.Background(), "orders"); err == nil {
+		t.Fatalf("expected second acquire to be rejected")
+	}
+
+	release()
+	release2, err := l.Acquire(context.Background(), "orders")
+	if err != nil {
+		t.Fatalf("acquire after release failed: %v", err)
+	}
+	release2()
+
+	if stats := l.Stats(); stats.InFlight != 0 || stats.Queued != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestConcurrencyLimiter_QueuesPerMethod(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{
+		MaxQueued: 1,
+		Limits:    map[string]int{"orders.Place": 1},
+	})
+
+	release, err := l.Acquire(context.Background(), "orders", "orders.Place")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	// other methods are not limited by the method limit
+	other, err := l.Acquire(context.Background(), "orders", "orders.Get")
+	if err != nil {
+		t.Fatalf("unrelated acquire failed: %v", err)
+	}
+	other()
+
+	done := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(context.Background(), "orders", "orders.Place")
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for l.Stats().Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiter was not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := l.Acquire(context.Background(), "orders", "orders.Place"); err == nil {
+		t.Fatalf("expected acquire beyond queue size to be rejected")
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("queued acquire failed: %v", err)
+	}
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyConfig{MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: 50})
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	defer release()
+
+	start := time.Now()
+	if _, err := l.Acquire(context.Background()); err != errQueueTimeout {
+		t.Fatalf("expected queue timeout, got %v", err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Fatalf("rejected before the queue timeout, after %s", waited)
+	}
+
+	if stats := l.Stats(); stats.InFlight != 1 || stats.Queued != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
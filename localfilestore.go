@@ -0,0 +1,680 @@
+package runtime
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FileStoreSidecar = "sidecar"
+	FileStoreLocal   = "local"
+)
+
+// LocalFileLinkPath is the ApiServer route serving the presigned links of the local file store
+const LocalFileLinkPath = "/v1/local-files"
+
+// DefaultLocalLinkExpiry is the expiry of a local presigned link when the request sets none
+const DefaultLocalLinkExpiry = 15 * time.Minute
+
+// FileStoreConfig selects the backend of the Folder and File api
+type FileStoreConfig struct {
+	// Backend is FileStoreSidecar, the default, or FileStoreLocal
+	Backend string `json:"backend"`
+	// LocalDir is the directory the local backend keeps files in
+	LocalDir string `json:"localDir"`
+	// LinkBaseUrl is the address local presigned links point to, empty uses the app port on localhost
+	LinkBaseUrl string `json:"linkBaseUrl"`
+}
+
+// LocalFileStoreProvider is implemented by ApiServer listeners backed by a local file store, the
+// server then serves the presigned links of the store
+type LocalFileStoreProvider interface {
+	LocalFileStore() *LocalFileStore
+}
+
+// LocalFileStore keeps the files of the file store in a directory on disk, for local development and
+// tests without the platform. Keys map to paths below the directory, folders to directories. Presigned
+// links are signed with a key generated when the store is created, so they only work while it is alive.
+//
+// Versioning, soft delete and multipart uploads are not supported, and content type, cache control,
+// metadata and temp file TTLs are not kept.
+type LocalFileStore struct {
+	dir         string
+	linkBaseUrl string
+	secret      []byte
+}
+
+func NewLocalFileStore(dir string, linkBaseUrl string) (*LocalFileStore, error) {
+	if dir == "" {
+		return nil, errors.New("client: local file store directory not set")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &LocalFileStore{
+		dir:         filepath.Clean(dir),
+		linkBaseUrl: strings.TrimSuffix(linkBaseUrl, "/"),
+		secret:      secret,
+	}, nil
+}
+
+// path maps a key to its location below the store directory, rejecting keys that climb out of it
+func (s *LocalFileStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("client: invalid file key %s", key)
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("client: invalid file key %s", key)
+		}
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(clean[1:])), nil
+}
+
+func (s *LocalFileStore) GetFile(req GetFileRequest) (GetFileResponse, error) {
+	if req.VersionId != "" {
+		return GetFileResponse{}, ErrLocalFileStoreUnsupported.With("file versions")
+	}
+
+	p, err := s.path(req.Key)
+	if err != nil {
+		return GetFileResponse{}, err
+	}
+
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return GetFileResponse{}, nil
+	}
+	if err != nil {
+		return GetFileResponse{}, err
+	}
+
+	return GetFileResponse{
+		Content: base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func (s *LocalFileStore) GetFileStream(req GetFileRequest) (io.ReadCloser, error) {
+	if req.VersionId != "" {
+		return nil, ErrLocalFileStoreUnsupported.With("file versions")
+	}
+
+	p, err := s.path(req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	return file, err
+}
+
+func (s *LocalFileStore) PutFile(req PutFileRequest) error {
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		return err
+	}
+
+	if req.ChecksumSha256 != "" {
+		checksum := sha256.Sum256(data)
+		if hex.EncodeToString(checksum[:]) != req.ChecksumSha256 {
+			return ErrChecksumMismatch.With(req.Key)
+		}
+	}
+
+	_, err = s.write(req.Key, bytes.NewReader(data), -1)
+	return err
+}
+
+func (s *LocalFileStore) PutFileStream(req PutFileRequest, body io.Reader) error {
+	_, err := s.write(req.Key, body, -1)
+	return err
+}
+
+// write stores body at key through a temp file renamed into place, failing once more than maxSize
+// bytes are read if maxSize is not negative
+func (s *LocalFileStore) write(key string, body io.Reader, maxSize int64) (int64, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if maxSize >= 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+
+	n, err := io.Copy(tmp, body)
+	if err != nil {
+		return n, err
+	}
+
+	if maxSize >= 0 && n > maxSize {
+		return n, ErrFileTooLarge.With(maxSize)
+	}
+
+	// CreateTemp creates the file owner-only, stored files get the usual permissions
+	if err = tmp.Chmod(0o644); err != nil {
+		return n, err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalFileStore) DeleteFile(req DeleteFileRequest) error {
+	if req.Soft {
+		return ErrLocalFileStoreUnsupported.With("soft delete")
+	}
+
+	p, err := s.path(req.Key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.prune(filepath.Dir(p))
+	return nil
+}
+
+// prune removes dir and its parents up to the store root as long as they are empty, so a
+// deleted folder is not listed back as a folder marker
+func (s *LocalFileStore) prune(dir string) {
+	for dir != s.dir && strings.HasPrefix(dir, s.dir+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (s *LocalFileStore) RenameFile(req RenameFileRequest) error {
+	from, err := s.path(req.OldKey)
+	if err != nil {
+		return err
+	}
+
+	to, err := s.path(req.NewKey)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+
+	err = os.Rename(from, to)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrFileNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.prune(filepath.Dir(from))
+	return nil
+}
+
+func (s *LocalFileStore) CopyFile(req CopyFileRequest) error {
+	body, err := s.GetFileStream(GetFileRequest{Key: req.SourceKey})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	_, err = s.write(req.DestKey, body, -1)
+	return err
+}
+
+func (s *LocalFileStore) PromoteFile(req PromoteFileRequest) error {
+	return s.RenameFile(RenameFileRequest{
+		OldKey: req.Key,
+		NewKey: req.DestKey,
+	})
+}
+
+func (s *LocalFileStore) StatFile(req StatFileRequest) (StatFileResponse, error) {
+	p, err := s.path(req.Key)
+	if err != nil {
+		return StatFileResponse{}, err
+	}
+
+	file, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return StatFileResponse{}, nil
+	}
+	if err != nil {
+		return StatFileResponse{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return StatFileResponse{}, err
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return StatFileResponse{}, err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	return StatFileResponse{
+		Exists:         true,
+		Key:            req.Key,
+		Size:           info.Size(),
+		ETag:           checksum,
+		ChecksumSha256: checksum,
+		ContentType:    mime.TypeByExtension(filepath.Ext(p)),
+		LastModified:   info.ModTime(),
+	}, nil
+}
+
+func (s *LocalFileStore) CreateFolder(req CreateFolderRequest) error {
+	p, err := s.path(req.Folder)
+	if err != nil {
+		return err
+	}
+
+	return os.MkdirAll(p, 0o755)
+}
+
+// ListFile lists every file below the prefix like the sidecar does, with keys relative to the prefix.
+// Empty directories are listed as folder markers ending in a slash, an empty prefix directory itself
+// as the empty key. The continuation token is the last key of the previous page.
+func (s *LocalFileStore) ListFile(req ListFilePageRequest) (ListFilePageResponse, error) {
+	p, err := s.path(req.Prefix)
+	if err != nil {
+		return ListFilePageResponse{}, err
+	}
+
+	var files []ListFileResponse
+	err = filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(p, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			entries, err := os.ReadDir(file)
+			if err == nil && len(entries) == 0 {
+				if rel == "." {
+					files = append(files, ListFileResponse{Key: ""})
+				} else {
+					files = append(files, ListFileResponse{Key: key + "/"})
+				}
+			}
+			return err
+		}
+
+		// temp files of writes in progress are not listed
+		if strings.HasPrefix(d.Name(), ".") && strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, ListFileResponse{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return ListFilePageResponse{}, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Key < files[j].Key
+	})
+
+	if req.ContinuationToken != nil {
+		after := *req.ContinuationToken
+		start := sort.Search(len(files), func(i int) bool {
+			return files[i].Key > after
+		})
+		files = files[start:]
+	}
+
+	res := ListFilePageResponse{
+		Files: files,
+	}
+
+	if req.MaxKeys > 0 && len(files) > int(req.MaxKeys) {
+		res.Files = files[:req.MaxKeys]
+		next := res.Files[len(res.Files)-1].Key
+		res.NextContinuationToken = &next
+		res.IsTruncated = true
+	}
+
+	return res, nil
+}
+
+func (s *LocalFileStore) GetFileDownloadLink(req GetDownloadLinkRequest) (GetLinkResponse, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	params := url.Values{}
+	if req.ContentDisposition != "" {
+		params.Set("disposition", req.ContentDisposition)
+	}
+	if req.ContentType != "" {
+		params.Set("contentType", req.ContentType)
+	}
+
+	return s.link(req.Key, method, req.Expiry, params, nil)
+}
+
+func (s *LocalFileStore) GetFileUploadLink(req GetUploadLinkRequest) (GetLinkResponse, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+
+	params := url.Values{}
+	var headers map[string]string
+	if req.ContentType != "" {
+		params.Set("contentType", req.ContentType)
+		headers = map[string]string{"Content-Type": req.ContentType}
+	}
+	if req.MaxSize > 0 {
+		params.Set("maxSize", strconv.FormatInt(req.MaxSize, 10))
+	}
+
+	return s.link(req.Key, method, req.Expiry, params, headers)
+}
+
+func (s *LocalFileStore) link(key string, method string, expiry int64, params url.Values, headers map[string]string) (GetLinkResponse, error) {
+	if _, err := s.path(key); err != nil {
+		return GetLinkResponse{}, err
+	}
+
+	if expiry <= 0 {
+		expiry = DefaultLocalLinkExpiry.Milliseconds()
+	}
+	expiresAt := time.Now().Add(time.Duration(expiry) * time.Millisecond).UnixMilli()
+
+	params.Set("method", method)
+	params.Set("expires", strconv.FormatInt(expiresAt, 10))
+	params.Set("signature", s.sign(key, params))
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return GetLinkResponse{
+		Link:      s.linkBaseUrl + LocalFileLinkPath + "/" + strings.Join(segments, "/") + "?" + params.Encode(),
+		Method:    method,
+		ExpiresAt: expiresAt,
+		Headers:   headers,
+	}, nil
+}
+
+// sign returns the signature of the key and every link parameter except the signature itself
+func (s *LocalFileStore) sign(key string, params url.Values) string {
+	unsigned := url.Values{}
+	for name, values := range params {
+		if name != "signature" {
+			unsigned[name] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "?" + unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves a presigned link, the request path is the key below LocalFileLinkPath
+func (s *LocalFileStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, LocalFileLinkPath), "/")
+	params := r.URL.Query()
+
+	if !hmac.Equal([]byte(params.Get("signature")), []byte(s.sign(key, params))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	expires, err := strconv.ParseInt(params.Get("expires"), 10, 64)
+	if err != nil || time.Now().UnixMilli() > expires {
+		http.Error(w, "link expired", http.StatusForbidden)
+		return
+	}
+
+	if r.Method != params.Get("method") {
+		http.Error(w, "method not allowed by link", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.serveDownload(w, r, key, params)
+	case http.MethodPut, http.MethodPost:
+		s.serveUpload(w, r, key, params)
+	default:
+		http.Error(w, "method not allowed by link", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *LocalFileStore) serveDownload(w http.ResponseWriter, r *http.Request, key string, params url.Values) {
+	p, err := s.path(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if disposition := params.Get("disposition"); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	if contentType := params.Get("contentType"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+func (s *LocalFileStore) serveUpload(w http.ResponseWriter, r *http.Request, key string, params url.Values) {
+	if contentType := params.Get("contentType"); contentType != "" && r.Header.Get("Content-Type") != contentType {
+		http.Error(w, "content type not allowed by link", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	maxSize := int64(-1)
+	if v := params.Get("maxSize"); v != "" {
+		maxSize, _ = strconv.ParseInt(v, 10, 64)
+	}
+
+	if n, err := s.write(key, r.Body, maxSize); err != nil {
+		if maxSize >= 0 && n > maxSize {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// localFileServiceClient routes the file store calls of a ServiceClient to a LocalFileStore, every
+// other call still goes to the sidecar
+type localFileServiceClient struct {
+	ServiceClient
+	store *LocalFileStore
+}
+
+func (c *localFileServiceClient) GetFile(sessionId string, req GetFileRequest) (GetFileResponse, error) {
+	return c.store.GetFile(req)
+}
+
+func (c *localFileServiceClient) GetFileStream(sessionId string, req GetFileRequest) (io.ReadCloser, error) {
+	return c.store.GetFileStream(req)
+}
+
+func (c *localFileServiceClient) GetFileDownloadLink(sessionId string, req GetDownloadLinkRequest) (GetLinkResponse, error) {
+	return c.store.GetFileDownloadLink(req)
+}
+
+func (c *localFileServiceClient) PutFile(sessionId string, req PutFileRequest) error {
+	return c.store.PutFile(req)
+}
+
+func (c *localFileServiceClient) PutFileStream(sessionId string, req PutFileRequest, body io.Reader) error {
+	return c.store.PutFileStream(req, body)
+}
+
+func (c *localFileServiceClient) GetFileUploadLink(sessionId string, req GetUploadLinkRequest) (GetLinkResponse, error) {
+	return c.store.GetFileUploadLink(req)
+}
+
+func (c *localFileServiceClient) DeleteFile(sessionId string, req DeleteFileRequest) error {
+	return c.store.DeleteFile(req)
+}
+
+func (c *localFileServiceClient) RenameFile(sessionId string, req RenameFileRequest) error {
+	return c.store.RenameFile(req)
+}
+
+func (c *localFileServiceClient) CopyFile(sessionId string, req CopyFileRequest) error {
+	return c.store.CopyFile(req)
+}
+
+func (c *localFileServiceClient) StatFile(sessionId string, req StatFileRequest) (StatFileResponse, error) {
+	return c.store.StatFile(req)
+}
+
+func (c *localFileServiceClient) PromoteFile(sessionId string, req PromoteFileRequest) error {
+	return c.store.PromoteFile(req)
+}
+
+func (c *localFileServiceClient) ListFile(sessionId string, req ListFilePageRequest) (ListFilePageResponse, error) {
+	return c.store.ListFile(req)
+}
+
+func (c *localFileServiceClient) CreateFolder(sessionId string, req CreateFolderRequest) error {
+	req.Folder = path.Join(req.parent, req.Folder)
+	return c.store.CreateFolder(req)
+}
+
+func (c *localFileServiceClient) SetFolderVersioning(string, SetFolderVersioningRequest) error {
+	return ErrLocalFileStoreUnsupported.With("file versions")
+}
+
+func (c *localFileServiceClient) ListFileVersions(string, ListFileVersionsRequest) (ListFileVersionsResponse, error) {
+	return ListFileVersionsResponse{}, ErrLocalFileStoreUnsupported.With("file versions")
+}
+
+func (c *localFileServiceClient) RestoreFileVersion(string, RestoreFileVersionRequest) error {
+	return ErrLocalFileStoreUnsupported.With("file versions")
+}
+
+func (c *localFileServiceClient) UndeleteFile(string, UndeleteFileRequest) error {
+	return ErrLocalFileStoreUnsupported.With("soft delete")
+}
+
+func (c *localFileServiceClient) CreateMultipartUpload(string, CreateMultipartUploadRequest) (CreateMultipartUploadResponse, error) {
+	return CreateMultipartUploadResponse{}, ErrLocalFileStoreUnsupported.With("multipart upload")
+}
+
+func (c *localFileServiceClient) GetPartUploadLink(string, GetPartUploadLinkRequest) (GetLinkResponse, error) {
+	return GetLinkResponse{}, ErrLocalFileStoreUnsupported.With("multipart upload")
+}
+
+func (c *localFileServiceClient) PutFilePart(string, PutFilePartRequest, io.Reader) (UploadedPart, error) {
+	return UploadedPart{}, ErrLocalFileStoreUnsupported.With("multipart upload")
+}
+
+func (c *localFileServiceClient) ListFileParts(string, ListFilePartsRequest) (ListFilePartsResponse, error) {
+	return ListFilePartsResponse{}, ErrLocalFileStoreUnsupported.With("multipart upload")
+}
+
+func (c *localFileServiceClient) CompleteMultipartUpload(string, CompleteMultipartUploadRequest) error {
+	return ErrLocalFileStoreUnsupported.With("multipart upload")
+}
+
+func (c *localFileServiceClient) AbortMultipartUpload(string, AbortMultipartUploadRequest) error {
+	return ErrLocalFileStoreUnsupported.With("multipart upload")
+}
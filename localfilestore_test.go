@@ -0,0 +1,195 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalFileStore(t *testing.T) (*LocalFileStore, Folder) {
+	var store *LocalFileStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := NewLocalFileStore(t.TempDir(), server.URL)
+	if err != nil {
+		t.Fatalf("failed to create local file store: %v", err)
+	}
+
+	client := &localFileServiceClient{ServiceClient: &mockServiceClient{}, store: store}
+	ctx := Context{sessionId: "s1", client: client}
+	return store, ctx.FileStore().(Folder)
+}
+
+func TestLocalFileStore_FileOperations(t *testing.T) {
+	store, root := newTestLocalFileStore(t)
+
+	reports, err := root.CreateNewFolder("reports")
+	if err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "files", "reports")); err != nil {
+		t.Fatalf("expected folder on disk: %v", err)
+	}
+
+	f := reports.File("q3.txt").(File)
+	if err := f.Save([]byte("revenue")); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	ok, data, err := f.Get()
+	if err != nil || !ok || string(data) != "revenue" {
+		t.Fatalf("unexpected content %q, ok %v, err %v", data, ok, err)
+	}
+
+	stored, err := os.Stat(filepath.Join(store.dir, "files", "reports", "q3.txt"))
+	if err != nil || stored.Mode().Perm() != 0o644 {
+		t.Fatalf("expected stored file with mode 0644, got %v (err=%v)", stored, err)
+	}
+
+	local := filepath.Join(t.TempDir(), "q3.txt")
+	if err := f.Download(local); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	downloaded, err := os.Stat(local)
+	if err != nil || downloaded.Mode().Perm() != 0o644 {
+		t.Fatalf("expected downloaded file with mode 0644, got %v (err=%v)", downloaded, err)
+	}
+
+	if err := f.Rename("q3-final.txt"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if ok, _, _ := f.Get(); ok {
+		t.Fatalf("expected old name to be gone")
+	}
+
+	renamed := reports.File("q3-final.txt").(File)
+	info, err := renamed.Stat()
+	if err != nil || info.Size != 7 {
+		t.Fatalf("unexpected stat %+v, err %v", info, err)
+	}
+
+	if _, err := reports.(Folder).CreateNewFolder("empty"); err != nil {
+		t.Fatalf("create folder failed: %v", err)
+	}
+
+	var names []string
+	it := reports.(Folder).List()
+	for it.Next() {
+		names = append(names, it.Entry().Name)
+	}
+	if it.Err() != nil || strings.Join(names, ",") != "empty,q3-final.txt" {
+		t.Fatalf("unexpected listing %v, err %v", names, it.Err())
+	}
+
+	if err := renamed.Delete(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if ok, _, _ := renamed.Get(); ok {
+		t.Fatalf("expected deleted file to be gone")
+	}
+
+	if err := renamed.SoftDelete(); err == nil {
+		t.Fatalf("expected soft delete to be unsupported")
+	}
+}
+
+func TestLocalFileStore_PresignedLinks(t *testing.T) {
+	_, root := newTestLocalFileStore(t)
+	f := root.File("upload.txt").(File)
+
+	upload, err := f.GetUploadLinkWithOptions(LinkOptions{ContentType: "text/plain", MaxSize: 16})
+	if err != nil {
+		t.Fatalf("upload link failed: %v", err)
+	}
+
+	put := func(link Link, contentType string, body string) int {
+		req, _ := http.NewRequest(link.Method, link.Url, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("upload request failed: %v", err)
+		}
+		_ = res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := put(upload, "text/plain", strings.Repeat("x", 32)); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized upload to be rejected, got %d", code)
+	}
+	if code := put(upload, "image/png", "hello"); code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected wrong content type to be rejected, got %d", code)
+	}
+	if code := put(upload, "text/plain", "hello"); code != http.StatusOK {
+		t.Fatalf("expected upload to succeed, got %d", code)
+	}
+
+	download, err := f.GetDownloadLinkWithOptions(LinkOptions{
+		Expiry:             time.Minute,
+		ContentDisposition: AttachmentDisposition("hello.txt"),
+	})
+	if err != nil {
+		t.Fatalf("download link failed: %v", err)
+	}
+	if download.ExpiresAt.Before(time.Now()) {
+		t.Fatalf("unexpected expiry %v", download.ExpiresAt)
+	}
+
+	res, err := http.Get(download.Url)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("unexpected download %d %q", res.StatusCode, body)
+	}
+	if res.Header.Get("Content-Disposition") != `attachment; filename=hello.txt` {
+		t.Fatalf("unexpected disposition %q", res.Header.Get("Content-Disposition"))
+	}
+
+	tampered := strings.Replace(download.Url, "upload.txt", "other.txt", 1)
+	res, err = http.Get(tampered)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected tampered link to be rejected, got %d", res.StatusCode)
+	}
+}
+
+func TestNewClientRuntime_ReportsLocalFileStoreError(t *testing.T) {
+	health := Health
+	t.Cleanup(func() {
+		Health = health
+	})
+	Health = NewHealthRegistry()
+
+	// a file where the store directory should be
+	dir := filepath.Join(t.TempDir(), "files")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	rt := NewClientRuntime(ClientEnv{
+		AppName:    "testApp",
+		SidecarApi: "http://127.0.0.1:1",
+		FileStore:  FileStoreConfig{Backend: FileStoreLocal, LocalDir: filepath.Join(dir, "store")},
+	})
+
+	if err := rt.Start(); err == nil || !strings.Contains(err.Error(), "local file store") {
+		t.Fatalf("expected start to report the file store error, got %v", err)
+	}
+	if err := rt.Serve(context.Background()); err == nil {
+		t.Fatalf("expected serve to report the file store error")
+	}
+}
@@ -11,11 +11,14 @@ type Memo struct {
 	ctx           context.Context
 	sessionId     string
 	serviceClient ServiceClient
+	key           string
+	retryPolicy   *RetryPolicy
 	getter        func() (any, error)
 }
 
 func (f Memo) Get() polycode.Response {
 	req1 := ExecFuncRequest{
+		Key:   f.key,
 		Input: nil,
 	}
 
@@ -30,6 +33,15 @@ func (f Memo) Get() polycode.Response {
 	}
 
 	if res1.IsCompleted {
+		if res1.Key != f.key {
+			fmt.Printf("client: memo key mismatch, recorded %q, requested %q\n", res1.Key, f.key)
+			return Response{
+				output:  nil,
+				isError: true,
+				error:   ErrMemoKeyMismatch.With(fmt.Sprintf("recorded %q, requested %q", res1.Key, f.key)),
+			}
+		}
+
 		return Response{
 			output:  res1.Output,
 			isError: res1.IsError,
@@ -40,6 +52,41 @@ func (f Memo) Get() polycode.Response {
 	output, err := f.getter()
 	var response Response
 	if err != nil {
+		attempt := res1.Attempt + 1
+		if f.retryPolicy != nil && f.retryPolicy.shouldRetry(attempt, err) {
+			// the failed attempt is recorded as non-final, the sidecar suspends the task
+			// on a durable timer and the getter runs again on re-execution
+			retryAfter := f.retryPolicy.backoff(attempt)
+			fmt.Printf("client: memo attempt %d failed, retry in %s: %v\n", attempt, retryAfter, err)
+
+			req2 := ExecFuncResult{
+				Key:        f.key,
+				Input:      nil,
+				IsError:    true,
+				Error:      ErrTaskExecError.Wrap(err),
+				IsRetry:    true,
+				Attempt:    attempt,
+				RetryAfter: retryAfter.Milliseconds(),
+			}
+
+			err = f.serviceClient.ExecFuncResult(f.sessionId, req2)
+			if err != nil {
+				fmt.Printf("client: exec func result error: %v\n", err)
+				return Response{
+					output:  nil,
+					isError: true,
+					error:   ErrTaskExecError.Wrap(err),
+				}
+			}
+
+			// the sidecar did not suspend the task, the retry is not scheduled
+			return Response{
+				output:  nil,
+				isError: true,
+				error:   ErrTaskExecError.Wrap(fmt.Errorf("memo retry was not scheduled")),
+			}
+		}
+
 		response = Response{
 			output:  nil,
 			isError: true,
@@ -54,6 +101,7 @@ func (f Memo) Get() polycode.Response {
 	}
 
 	req2 := ExecFuncResult{
+		Key:     f.key,
 		Input:   nil,
 		Output:  response.output,
 		IsError: response.isError,
@@ -72,3 +120,30 @@ func (f Memo) Get() polycode.Response {
 
 	return response
 }
+
+type keyedMemoizer interface {
+	MemoKey(key string, getter func() (any, error)) polycode.Response
+}
+
+// MemoValue runs the getter once and returns the recorded result as T on every re-execution.
+// A non-empty key is recorded with the result and checked on replay, an empty key falls
+// back to a positional memo.
+func MemoValue[T any](ctx polycode.WorkflowContext, key string, getter func() (T, error)) (T, error) {
+	var ret T
+
+	wrapped := func() (any, error) {
+		return getter()
+	}
+
+	var res polycode.Response
+	if key == "" {
+		res = ctx.Memo(wrapped)
+	} else if m, ok := ctx.(keyedMemoizer); ok {
+		res = m.MemoKey(key, wrapped)
+	} else {
+		return ret, ErrTaskExecError.Wrap(fmt.Errorf("keyed memo not supported by context %T", ctx))
+	}
+
+	err := res.Get(&ret)
+	return ret, err
+}
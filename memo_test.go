@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoServiceClient struct {
+	*mockServiceClient
+	recorded *ExecFuncResponse
+	results  []ExecFuncResult
+}
+
+func (m *memoServiceClient) ExecFunc(sessionId string, req ExecFuncRequest) (ExecFuncResponse, error) {
+	if m.recorded != nil {
+		return *m.recorded, nil
+	}
+	return ExecFuncResponse{}, nil
+}
+
+func (m *memoServiceClient) ExecFuncResult(sessionId string, req ExecFuncResult) error {
+	m.results = append(m.results, req)
+	return nil
+}
+
+func TestMemoKey_RecordsKey(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	res := c.MemoKey("charge", func() (any, error) {
+		return "charged", nil
+	})
+
+	if res.IsError() {
+		t.Fatalf("unexpected error response")
+	}
+	if len(mock.results) != 1 || mock.results[0].Key != "charge" {
+		t.Fatalf("expected result recorded with key, got %+v", mock.results)
+	}
+}
+
+func TestMemoKey_ReplayMismatch(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: true,
+			Key:         "reserve",
+			Output:      "reserved",
+		},
+	}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	called := false
+	res := c.MemoKey("charge", func() (any, error) {
+		called = true
+		return "charged", nil
+	})
+
+	if called {
+		t.Fatalf("getter must not run on replay")
+	}
+	if !res.IsError() {
+		t.Fatalf("expected key mismatch error")
+	}
+}
+
+func TestMemoValue_Typed(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: true,
+			Key:         "count",
+			Output:      float64(42),
+		},
+	}
+	c := &Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	n, err := MemoValue(c, "count", func() (int, error) {
+		return 0, nil
+	})
+
+	if err != nil || n != 42 {
+		t.Fatalf("expected replayed value 42, got %d (err=%v)", n, err)
+	}
+}
+
+func TestMemoWithRetry_RecordsNonFinalAttempt(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: false,
+			Attempt:     1,
+		},
+	}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Second, BackoffCoefficient: 2}
+	c.MemoWithRetry("charge", policy, func() (any, error) {
+		return nil, errors.New("gateway unavailable")
+	})
+
+	if len(mock.results) != 1 {
+		t.Fatalf("expected one recorded attempt, got %+v", mock.results)
+	}
+	r := mock.results[0]
+	if !r.IsRetry || r.Attempt != 2 || r.RetryAfter != (2*time.Second).Milliseconds() {
+		t.Fatalf("expected non-final second attempt with 2s backoff, got %+v", r)
+	}
+}
+
+func TestMemoWithRetry_ExhaustedIsFinal(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded: &ExecFuncResponse{
+			IsCompleted: false,
+			Attempt:     2,
+		},
+	}
+	c := Context{ctx: context.Background(), sessionId: "sess-1", client: mock}
+
+	policy := RetryPolicy{MaxAttempts: 3}
+	res := c.MemoWithRetry("charge", policy, func() (any, error) {
+		return nil, errors.New("gateway unavailable")
+	})
+
+	if !res.IsError() {
+		t.Fatalf("expected error response")
+	}
+	if len(mock.results) != 1 || mock.results[0].IsRetry {
+		t.Fatalf("expected final failure to be recorded, got %+v", mock.results)
+	}
+}
@@ -0,0 +1,350 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// MinPartSize is the smallest part the file store accepts, except for the last part of an upload
+	MinPartSize int64 = 5 * 1024 * 1024
+	// DefaultPartSize is the part size used by UploadResumable when none is set
+	DefaultPartSize int64 = 8 * 1024 * 1024
+	// DefaultPartConcurrency is the number of parts UploadResumable sends at once when none is set
+	DefaultPartConcurrency = 4
+)
+
+// DefaultPartRetryPolicy retries a failed part upload a few times before the upload is given up
+var DefaultPartRetryPolicy = RetryPolicy{
+	MaxAttempts:        5,
+	InitialInterval:    500 * time.Millisecond,
+	BackoffCoefficient: 2,
+	MaxInterval:        30 * time.Second,
+}
+
+// MultipartUpload is an upload whose content is sent as separately uploaded parts, either through the
+// sidecar or directly to presigned part links, and assembled by Complete
+type MultipartUpload struct {
+	file     File
+	uploadId string
+}
+
+// ResumableOptions controls File.UploadResumable
+type ResumableOptions struct {
+	FileAttributes
+	// UploadId of an earlier attempt to resume, parts it already uploaded are skipped
+	UploadId    string
+	PartSize    int64
+	Concurrency int
+	// RetryPolicy applies to each part, nil uses DefaultPartRetryPolicy
+	RetryPolicy *RetryPolicy
+	// Progress is called with the total number of bytes uploaded so far, including resumed parts
+	Progress func(transferred int64)
+}
+
+// CreateMultipartUpload starts a multipart upload to the file
+func (f File) CreateMultipartUpload(attributes FileAttributes) (*MultipartUpload, error) {
+	if err := f.checkAttributes(attributes); err != nil {
+		return nil, err
+	}
+
+	req := CreateMultipartUploadRequest{
+		Key:          f.Path(),
+		TempFile:     f.temp,
+		TTL:          attributes.TTL.Milliseconds(),
+		ContentType:  attributes.ContentType,
+		CacheControl: attributes.CacheControl,
+		Metadata:     attributes.Metadata,
+	}
+
+	res, err := f.client.CreateMultipartUpload(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to create multipart upload: %s\n", err.Error())
+		return nil, err
+	}
+
+	return &MultipartUpload{
+		file:     f,
+		uploadId: res.UploadId,
+	}, nil
+}
+
+// MultipartUpload returns a handle to an upload started earlier, for example by another invocation
+func (f File) MultipartUpload(uploadId string) *MultipartUpload {
+	return &MultipartUpload{
+		file:     f,
+		uploadId: uploadId,
+	}
+}
+
+func (m *MultipartUpload) UploadId() string {
+	return m.uploadId
+}
+
+// PartLink returns a presigned link the client can PUT the given part to, part numbers start from 1
+func (m *MultipartUpload) PartLink(partNumber int32, expiry time.Duration) (Link, error) {
+	if expiry < 0 {
+		return Link{}, ErrInvalidLinkOptions.With("negative expiry")
+	}
+
+	req := GetPartUploadLinkRequest{
+		Key:        m.file.Path(),
+		UploadId:   m.uploadId,
+		PartNumber: partNumber,
+		Expiry:     expiry.Milliseconds(),
+	}
+
+	res, err := m.file.client.GetPartUploadLink(m.file.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to get part link: %s\n", err.Error())
+		return Link{}, err
+	}
+
+	return toLink(res, "PUT")
+}
+
+// UploadPart streams one part through the sidecar
+func (m *MultipartUpload) UploadPart(partNumber int32, body io.Reader) (UploadedPart, error) {
+	req := PutFilePartRequest{
+		Key:        m.file.Path(),
+		UploadId:   m.uploadId,
+		PartNumber: partNumber,
+	}
+
+	part, err := m.file.client.PutFilePart(m.file.sessionId, req, body)
+	if err != nil {
+		fmt.Printf("failed to upload part %d: %s\n", partNumber, err.Error())
+		return UploadedPart{}, err
+	}
+
+	return part, nil
+}
+
+// Parts returns the parts the file store has accepted so far, including those uploaded through part links
+func (m *MultipartUpload) Parts() ([]UploadedPart, error) {
+	req := ListFilePartsRequest{
+		Key:      m.file.Path(),
+		UploadId: m.uploadId,
+	}
+
+	res, err := m.file.client.ListFileParts(m.file.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to list parts: %s\n", err.Error())
+		return nil, err
+	}
+
+	return res.Parts, nil
+}
+
+// Complete assembles the parts into the file, nil parts completes with every part the file store has accepted
+func (m *MultipartUpload) Complete(parts []UploadedPart) error {
+	if parts == nil {
+		var err error
+		if parts, err = m.Parts(); err != nil {
+			return err
+		}
+	}
+
+	sorted := append([]UploadedPart(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PartNumber < sorted[j].PartNumber
+	})
+
+	req := CompleteMultipartUploadRequest{
+		Key:      m.file.Path(),
+		UploadId: m.uploadId,
+		Parts:    sorted,
+	}
+
+	err := m.file.client.CompleteMultipartUpload(m.file.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to complete multipart upload: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Abort discards the upload and every part uploaded for it
+func (m *MultipartUpload) Abort() error {
+	req := AbortMultipartUploadRequest{
+		Key:      m.file.Path(),
+		UploadId: m.uploadId,
+	}
+
+	err := m.file.client.AbortMultipartUpload(m.file.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to abort multipart upload: %s\n", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UploadResumable uploads the local file at filePath in parts, retrying each part on its own. If the
+// upload still fails the returned error carries the upload id, which can be passed back in
+// ResumableOptions.UploadId to continue from the parts already uploaded, or used to Abort it.
+func (f File) UploadResumable(filePath string, options ResumableOptions) (*MultipartUpload, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		fmt.Printf("failed to open file: %s\n", err.Error())
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	partSize := options.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	} else if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPartConcurrency
+	}
+
+	policy := DefaultPartRetryPolicy
+	if options.RetryPolicy != nil {
+		policy = *options.RetryPolicy
+	}
+
+	var upload *MultipartUpload
+	done := make(map[int32]UploadedPart)
+	if options.UploadId == "" {
+		if upload, err = f.CreateMultipartUpload(options.FileAttributes); err != nil {
+			return nil, err
+		}
+	} else {
+		upload = f.MultipartUpload(options.UploadId)
+		parts, err := upload.Parts()
+		if err != nil {
+			return upload, ErrMultipartUploadFailed.With(upload.uploadId).Wrap(err)
+		}
+
+		for _, part := range parts {
+			done[part.PartNumber] = part
+		}
+	}
+
+	partCount := int32((stat.Size() + partSize - 1) / partSize)
+	if partCount == 0 {
+		// an empty file is still uploaded as a single empty part
+		partCount = 1
+	}
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		firstErr    error
+		transferred int64
+		parts       []UploadedPart
+	)
+
+	progress := func(n int64) {
+		transferred += n
+		if options.Progress != nil {
+			options.Progress(transferred)
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	for partNumber := int32(1); partNumber <= partCount; partNumber++ {
+		offset := int64(partNumber-1) * partSize
+		size := partSize
+		if offset+size > stat.Size() {
+			size = stat.Size() - offset
+		}
+
+		if part, ok := done[partNumber]; ok && part.Size == size {
+			mu.Lock()
+			parts = append(parts, part)
+			progress(size)
+			mu.Unlock()
+			continue
+		}
+
+		sem <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(partNumber int32, offset int64, size int64) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			part, err := upload.uploadPartWithRetry(policy, partNumber, io.NewSectionReader(file, offset, size))
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			if part.PartNumber == 0 {
+				part.PartNumber = partNumber
+			}
+			if part.Size == 0 {
+				part.Size = size
+			}
+			parts = append(parts, part)
+			progress(size)
+		}(partNumber, offset, size)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return upload, ErrMultipartUploadFailed.With(upload.uploadId).Wrap(firstErr)
+	}
+
+	if err = upload.Complete(parts); err != nil {
+		return upload, ErrMultipartUploadFailed.With(upload.uploadId).Wrap(err)
+	}
+
+	return upload, nil
+}
+
+func (m *MultipartUpload) uploadPartWithRetry(policy RetryPolicy, partNumber int32, section *io.SectionReader) (UploadedPart, error) {
+	ctx := Invocations.Context(m.file.sessionId)
+	for attempt := 1; ; attempt++ {
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return UploadedPart{}, err
+		}
+
+		part, err := m.UploadPart(partNumber, section)
+		if err == nil {
+			return part, nil
+		}
+
+		if !policy.shouldRetry(attempt, err) {
+			return UploadedPart{}, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return UploadedPart{}, err
+		}
+	}
+}
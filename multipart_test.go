@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, size int64) string {
+	path := filepath.Join(t.TempDir(), "video.bin")
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatalf("write test file failed: %v", err)
+	}
+	return path
+}
+
+func TestFile_UploadResumableRetriesParts(t *testing.T) {
+	client := newFileServiceClient(nil)
+	client.failPart, client.failures = 2, 2
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("video.bin").(File)
+	path := writeTestFile(t, 2*MinPartSize+10)
+
+	var progress int64
+	_, err := f.UploadResumable(path, ResumableOptions{
+		PartSize:    MinPartSize,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond},
+		Progress:    func(n int64) { progress = n },
+	})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	if client.uploads[2] != 3 {
+		t.Fatalf("expected part 2 to be attempted 3 times, got %d", client.uploads[2])
+	}
+	if len(client.completed) != 3 || client.completed[0].PartNumber != 1 || client.completed[2].Size != 10 {
+		t.Fatalf("unexpected completed parts %+v", client.completed)
+	}
+	if progress != 2*MinPartSize+10 {
+		t.Fatalf("unexpected progress %d", progress)
+	}
+}
+
+func TestFile_UploadResumableResumesFromUploadedParts(t *testing.T) {
+	client := newFileServiceClient(nil)
+	client.failPart, client.failures = 2, 1
+	f := Folder{client: client, sessionId: "s1", name: "files"}.File("video.bin").(File)
+	path := writeTestFile(t, 2*MinPartSize+10)
+
+	upload, err := f.UploadResumable(path, ResumableOptions{
+		PartSize:    MinPartSize,
+		Concurrency: 1,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 1},
+	})
+	if err == nil {
+		t.Fatalf("expected first attempt to fail")
+	}
+	if upload == nil || upload.UploadId() != "upload-1" {
+		t.Fatalf("expected the failed upload to be returned for resuming")
+	}
+
+	_, err = f.UploadResumable(path, ResumableOptions{
+		UploadId:    upload.UploadId(),
+		PartSize:    MinPartSize,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	if client.uploads[1] != 1 {
+		t.Fatalf("expected part 1 to be uploaded once, got %d", client.uploads[1])
+	}
+	if len(client.completed) != 3 {
+		t.Fatalf("unexpected completed parts %+v", client.completed)
+	}
+}
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"math"
+	"time"
+)
+
+// RetryPolicy controls how often a failing memoized side effect is retried. Attempts are
+// separated by durable timers, so the task is suspended between attempts instead of
+// holding the invocation open.
+type RetryPolicy struct {
+	MaxAttempts        int
+	InitialInterval    time.Duration
+	BackoffCoefficient float64
+	MaxInterval        time.Duration
+	// IsRetryable classifies errors returned by the getter, nil retries every error
+	IsRetryable func(err error) bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:        3,
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2,
+	MaxInterval:        time.Minute,
+}
+
+// shouldRetry reports whether another attempt is allowed after the given attempt failed with err
+func (p RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if p.IsRetryable != nil && !p.IsRetryable(err) {
+		return false
+	}
+
+	return true
+}
+
+// backoff returns the wait before the attempt following the given failed attempt, counting from 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	interval := p.InitialInterval
+	if interval <= 0 {
+		interval = DefaultRetryPolicy.InitialInterval
+	}
+
+	coefficient := p.BackoffCoefficient
+	if coefficient < 1 {
+		coefficient = DefaultRetryPolicy.BackoffCoefficient
+	}
+
+	limit := p.MaxInterval
+	if limit <= 0 {
+		limit = time.Duration(math.MaxInt64)
+	}
+
+	// compare before converting, a float beyond the int64 range does not convert to a usable duration
+	wait := float64(interval) * math.Pow(coefficient, float64(attempt-1))
+	if math.IsNaN(wait) || wait <= 0 || wait >= float64(limit) {
+		return limit
+	}
+
+	return time.Duration(wait)
+}
@@ -0,0 +1,23 @@
+package runtime
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_BackoffClampsOverflow(t *testing.T) {
+	unbounded := RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 10}
+	if wait := unbounded.backoff(100); wait != time.Duration(math.MaxInt64) {
+		t.Fatalf("expected overflowed wait to clamp to the max duration, got %s", wait)
+	}
+
+	bounded := RetryPolicy{InitialInterval: time.Second, BackoffCoefficient: 10, MaxInterval: time.Minute}
+	if wait := bounded.backoff(100); wait != time.Minute {
+		t.Fatalf("expected overflowed wait to clamp to MaxInterval, got %s", wait)
+	}
+
+	if wait := bounded.backoff(2); wait != 10*time.Second {
+		t.Fatalf("expected 10s backoff, got %s", wait)
+	}
+}
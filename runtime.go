@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"log"
 	"runtime/debug"
+	"time"
 )
 
 var CurrentRuntime Runtime
@@ -28,14 +29,33 @@ type ServiceStartEvent struct {
 	Meta        polycode.HandlerContextMeta `json:"meta"`
 	AuthContext polycode.AuthContext        `json:"authContext"`
 	Input       any                         `json:"input"`
+	IsCancelled bool                        `json:"isCancelled"`
+	// Deadline is the unix time in milliseconds the task must complete by, derived by the sidecar
+	// from the caller's task options, 0 if the caller set no timeout
+	Deadline int64 `json:"deadline"`
+	// StartedAt is the unix time in milliseconds the task was first started, it stays the same when a
+	// workflow is re-executed after a suspension
+	StartedAt int64 `json:"startedAt"`
 }
 
 type ServiceCompleteEvent struct {
-	IsError    bool               `json:"isError"`
-	Output     any                `json:"output"`
-	Error      errors2.Error      `json:"error"`
-	Stacktrace errors2.Stacktrace `json:"stacktrace"`
-	Logs       []LogMsg           `json:"logs"`
+	IsError     bool               `json:"isError"`
+	IsCancelled bool               `json:"isCancelled"`
+	IsTimeout   bool               `json:"isTimeout"`
+	IsRetryable bool               `json:"isRetryable"`
+	Output      any                `json:"output"`
+	Error       errors2.Error      `json:"error"`
+	Stacktrace  errors2.Stacktrace `json:"stacktrace"`
+	Logs        []LogMsg           `json:"logs"`
+}
+
+type CancelInvocationRequest struct {
+	SessionId string `json:"sessionId"`
+	Reason    string `json:"reason"`
+}
+
+type CancelInvocationResponse struct {
+	Cancelled bool `json:"cancelled"`
 }
 
 type ApiStartEvent struct {
@@ -65,18 +85,80 @@ type Runtime interface {
 	RegisterService(service Service) error
 	RegisterApi(httpHandler *gin.Engine) error
 	RegisterValidator(validator polycode.Validator) error
+	RegisterVersion(service string, method string, version VersionDescription) error
+	RegisterTimeout(service string, method string, timeout time.Duration) error
+	RegisterServiceInterceptor(interceptor ServiceInterceptor) error
+	RegisterApiInterceptor(interceptor ApiInterceptor) error
 	GetValidator() polycode.Validator
 	RunService(ctx context.Context, event ServiceStartEvent) (evt ServiceCompleteEvent)
 	RunApi(ctx context.Context, event ApiStartEvent) (evt ApiCompleteEvent)
 	Start() error
+	Serve(ctx context.Context) error
+	Stop() error
 }
 
 type ClientRuntime struct {
-	env         ClientEnv
-	serviceMap  map[string]ClientService
-	httpHandler *gin.Engine
-	client      ServiceClient
-	validator   polycode.Validator
+	env          ClientEnv
+	serviceMap   map[string]ClientService
+	versionMap   map[string][]VersionDescription
+	timeoutMap   map[string]time.Duration
+	interceptors *interceptorChain
+	httpHandler  *gin.Engine
+	client       ServiceClient
+	localFiles   *LocalFileStore
+	validator    polycode.Validator
+	// initErr is a configuration error found by NewClientRuntime, reported by Start and Serve
+	initErr error
+}
+
+// NewClientRuntime creates the runtime for the env. A file store that cannot be opened is reported by
+// Start and Serve.
+func NewClientRuntime(env ClientEnv) ClientRuntime {
+	client := NewServiceClient(env.SidecarApi)
+
+	err := Health.Register("sidecar", func(ctx context.Context) error {
+		return client.Ping(ctx)
+	})
+	if err != nil {
+		log.Printf("client: %s\n", err.Error())
+	}
+
+	var localFiles *LocalFileStore
+	var initErr error
+	if env.FileStore.Backend == FileStoreLocal {
+		linkBaseUrl := env.FileStore.LinkBaseUrl
+		if linkBaseUrl == "" {
+			linkBaseUrl = fmt.Sprintf("http://localhost:%d", env.AppPort)
+		}
+
+		localFiles, err = NewLocalFileStore(env.FileStore.LocalDir, linkBaseUrl)
+		if err != nil {
+			initErr = fmt.Errorf("client: failed to open local file store: %w", err)
+		} else {
+			log.Printf("client: using local file store at %s\n", env.FileStore.LocalDir)
+			client = &localFileServiceClient{
+				ServiceClient: client,
+				store:         localFiles,
+			}
+		}
+	}
+
+	return ClientRuntime{
+		env:          env,
+		serviceMap:   make(map[string]ClientService),
+		versionMap:   make(map[string][]VersionDescription),
+		timeoutMap:   make(map[string]time.Duration),
+		interceptors: &interceptorChain{},
+		client:       client,
+		localFiles:   localFiles,
+		validator:    DummyValidator{},
+		initErr:      initErr,
+	}
+}
+
+// LocalFileStore returns the local file store backing the file api, nil if files are kept by the sidecar
+func (c ClientRuntime) LocalFileStore() *LocalFileStore {
+	return c.localFiles
 }
 
 func (c ClientRuntime) getService(serviceName string) (ClientService, error) {
@@ -123,16 +205,82 @@ func (c ClientRuntime) RegisterValidator(validator polycode.Validator) error {
 	return nil
 }
 
+// RegisterVersion declares a change point of a workflow method so the sidecar can report which
+// versions are still in flight and reject executions the code no longer supports
+func (c ClientRuntime) RegisterVersion(service string, method string, version VersionDescription) error {
+	if version.MinVersion > version.MaxVersion {
+		return fmt.Errorf("client: invalid version range [%d, %d] for %s", version.MinVersion, version.MaxVersion, version.ChangeId)
+	}
+
+	key := service + "." + method
+	for _, v := range c.versionMap[key] {
+		if v.ChangeId == version.ChangeId {
+			return fmt.Errorf("client: version %s already registered for %s", version.ChangeId, key)
+		}
+	}
+
+	c.versionMap[key] = append(c.versionMap[key], version)
+	return nil
+}
+
+// RegisterTimeout sets the execution deadline of a method, counted from the first start of the task
+func (c ClientRuntime) RegisterTimeout(service string, method string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("client: invalid timeout %s for %s.%s", timeout, service, method)
+	}
+
+	c.timeoutMap[service+"."+method] = timeout
+	return nil
+}
+
+func (c ClientRuntime) RegisterServiceInterceptor(interceptor ServiceInterceptor) error {
+	if interceptor == nil {
+		return errors.New("client: service interceptor is nil")
+	}
+
+	if c.interceptors == nil {
+		return errors.New("client: runtime has no interceptor chain, create it with NewClientRuntime")
+	}
+
+	c.interceptors.addService(interceptor)
+	return nil
+}
+
+func (c ClientRuntime) RegisterApiInterceptor(interceptor ApiInterceptor) error {
+	if interceptor == nil {
+		return errors.New("client: api interceptor is nil")
+	}
+
+	if c.interceptors == nil {
+		return errors.New("client: runtime has no interceptor chain, create it with NewClientRuntime")
+	}
+
+	c.interceptors.addApi(interceptor)
+	return nil
+}
+
 func (c ClientRuntime) GetValidator() polycode.Validator {
 	return c.validator
 }
 
+// Start registers the app with the sidecar, reporting the supported versions of every change point
 func (c ClientRuntime) Start() error {
+	if c.initErr != nil {
+		return c.initErr
+	}
+
 	services, err := ExtractServiceDescription(c.serviceMap)
 	if err != nil {
 		return fmt.Errorf("client: failed to extract service description: %w", err)
 	}
 
+	for i, srv := range services {
+		for j, task := range srv.Tasks {
+			services[i].Tasks[j].Versions = c.versionMap[srv.Name+"."+task.Name]
+			services[i].Tasks[j].Timeout = c.timeoutMap[srv.Name+"."+task.Name].Milliseconds()
+		}
+	}
+
 	req := StartAppRequest{
 		AppName:  c.env.AppName,
 		AppPort:  c.env.AppPort,
@@ -147,20 +295,70 @@ func (c ClientRuntime) Start() error {
 		}
 	}
 
+	Health.MarkStarted()
 	return nil
 }
 
+// Serve runs the api server the sidecar invokes the app through on the app port, admitting invocations
+// within the concurrency limits of the env. It returns once ctx is cancelled or the process is signalled
+// to stop and the in-flight invocations are drained.
+func (c ClientRuntime) Serve(ctx context.Context) error {
+	if c.initErr != nil {
+		return c.initErr
+	}
+
+	return NewApiServer(c, c.env).Start(ctx, int(c.env.AppPort))
+}
+
+func (c ClientRuntime) Stop() error {
+	req := StopAppRequest{
+		AppName: c.env.AppName,
+		AppPort: c.env.AppPort,
+		Reason:  "shutdown",
+	}
+
+	return c.client.StopApp(req)
+}
+
 func (c ClientRuntime) RunService(ctx context.Context, event ServiceStartEvent) (evt ServiceCompleteEvent) {
 	fmt.Printf("service started %s.%s", event.Service, event.Method)
 
+	// the deadline is applied before registering the invocation so sidecar calls abort with it
+	if deadline, ok := c.deadlineFor(event); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	// runs after the invocation is released, so the cleanup is not cut short by an expired deadline
+	// and runs under its own bounded invocation
+	stopped := false
+	defer func() {
+		finishSessionTempFiles(c.client, event.SessionId, !stopped && !evt.IsRetryable)
+	}()
+
+	ctx, watcher := watchDeadline(ctx)
+	ctx, release := Invocations.Register(ctx, event.SessionId)
+	defer release()
+
+	// a task cancelled by its parent is re-executed with a cancelled context so the handler sees it in Done(),
+	// it runs detached so it can still replay memos and compensate
+	if event.IsCancelled {
+		defer Invocations.Detach(event.SessionId)()
+		Invocations.Cancel(event.SessionId, "cancelled by parent task")
+	}
+
 	defer func() {
 		// Recover from panic and check for a specific error
 		if r := recover(); r != nil {
+			// the panicking frames are still on the stack while deferred calls run
+			watcher.capture()
 			recovered, ok := r.(error)
 
 			if ok {
 				if errors.Is(recovered, ErrTaskStopped) {
 					fmt.Printf("service stopped %s.%s", event.Service, event.Method)
+					stopped = true
 					evt = ValueToServiceComplete(nil)
 				} else {
 					stackTrace := string(debug.Stack())
@@ -178,6 +376,10 @@ func (c ClientRuntime) RunService(ctx context.Context, event ServiceStartEvent)
 				err2 := ErrInternal.Wrap(errors.New(errorStr))
 				evt = ErrorToServiceComplete(err2, stackTrace)
 			}
+
+			if evt.IsError && ctx.Err() != nil {
+				evt = interruptedToServiceComplete(ctx, event, watcher.Stack())
+			}
 		}
 	}()
 
@@ -217,13 +419,25 @@ func (c ClientRuntime) RunService(ctx context.Context, event ServiceStartEvent)
 		authCtx:   event.AuthContext,
 	}
 
-	var ret any
-	if service.IsWorkflow(event.Method) {
-		fmt.Printf("service %s exec workflow %s with session id %s", event.Service, event.Method, event.SessionId)
-		ret, err = service.ExecuteWorkflow(ctxImpl, event.Method, inputObj)
-	} else {
-		fmt.Printf("service %s exec handler %s with session id %s", event.Service, event.Method, event.SessionId)
-		ret, err = service.ExecuteService(ctxImpl, event.Method, inputObj)
+	inv := ServiceInvocation{
+		Event:      event,
+		Context:    ctxImpl,
+		Input:      inputObj,
+		IsWorkflow: service.IsWorkflow(event.Method),
+	}
+
+	ret, err := c.interceptors.runService(inv, func(inv ServiceInvocation) (any, error) {
+		if inv.IsWorkflow {
+			fmt.Printf("service %s exec workflow %s with session id %s", event.Service, event.Method, event.SessionId)
+			return service.ExecuteWorkflow(inv.Context, event.Method, inv.Input)
+		} else {
+			fmt.Printf("service %s exec handler %s with session id %s", event.Service, event.Method, event.SessionId)
+			return service.ExecuteService(inv.Context, event.Method, inv.Input)
+		}
+	})
+
+	if ctx.Err() != nil {
+		return interruptedToServiceComplete(ctx, event, watcher.Stack())
 	}
 
 	if err != nil {
@@ -240,6 +454,14 @@ func (c ClientRuntime) RunService(ctx context.Context, event ServiceStartEvent)
 func (c ClientRuntime) RunApi(ctx context.Context, event ApiStartEvent) (evt ApiCompleteEvent) {
 	fmt.Printf("api started %s %s", event.Request.Method, event.Request.Path)
 
+	stopped := false
+	defer func() {
+		finishSessionTempFiles(c.client, event.SessionId, !stopped)
+	}()
+
+	ctx, release := Invocations.Register(ctx, event.SessionId)
+	defer release()
+
 	defer func() {
 		// Recover from panic and check for a specific error
 		if r := recover(); r != nil {
@@ -248,6 +470,7 @@ func (c ClientRuntime) RunApi(ctx context.Context, event ApiStartEvent) (evt Api
 			if ok {
 				if errors.Is(recovered, ErrTaskStopped) {
 					fmt.Printf("api stopped %s %s", event.Request.Method, event.Request.Path)
+					stopped = true
 					evt = ApiCompleteEvent{
 						Response: polycode.ApiResponse{
 							StatusCode:      202,
@@ -311,7 +534,21 @@ func (c ClientRuntime) RunApi(ctx context.Context, event ApiStartEvent) (evt Api
 		return ErrorToApiComplete(err2)
 	}
 
-	res := ManualInvokeHandler(c.httpHandler, httpReq)
+	inv := ApiInvocation{
+		Event:   event,
+		Context: ctxImpl,
+		Request: httpReq,
+	}
+
+	res, err := c.interceptors.runApi(inv, func(inv ApiInvocation) (polycode.ApiResponse, error) {
+		return ManualInvokeHandler(c.httpHandler, inv.Request), nil
+	})
+	if err != nil {
+		err2 := ErrApiExecError.Wrap(err)
+		fmt.Printf("api rejected %s %s, reason: %s\n", event.Request.Method, event.Request.Path, err.Error())
+		return ErrorToApiComplete(err2)
+	}
+
 	fmt.Printf("api completed %s %s\n", event.Request.Method, event.Request.Path)
 	return ApiCompleteEvent{
 		Response: res,
@@ -330,6 +567,22 @@ func RegisterValidator(validator polycode.Validator) error {
 	return CurrentRuntime.RegisterValidator(validator)
 }
 
+func RegisterVersion(service string, method string, version VersionDescription) error {
+	return CurrentRuntime.RegisterVersion(service, method, version)
+}
+
+func RegisterTimeout(service string, method string, timeout time.Duration) error {
+	return CurrentRuntime.RegisterTimeout(service, method, timeout)
+}
+
+func RegisterServiceInterceptor(interceptor ServiceInterceptor) error {
+	return CurrentRuntime.RegisterServiceInterceptor(interceptor)
+}
+
+func RegisterApiInterceptor(interceptor ApiInterceptor) error {
+	return CurrentRuntime.RegisterApiInterceptor(interceptor)
+}
+
 func GetValidator() polycode.Validator {
 	return CurrentRuntime.GetValidator()
 }
@@ -337,3 +590,11 @@ func GetValidator() polycode.Validator {
 func Start() error {
 	return CurrentRuntime.Start()
 }
+
+func Serve(ctx context.Context) error {
+	return CurrentRuntime.Serve(ctx)
+}
+
+func Stop() error {
+	return CurrentRuntime.Stop()
+}
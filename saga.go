@@ -0,0 +1,154 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"github.com/cloudimpl/polycode-sdk-go/errors"
+)
+
+// Compensation is the service call that undoes a completed saga step
+type Compensation struct {
+	EnvId   string               `json:"envId"`
+	Service string               `json:"service"`
+	Method  string               `json:"method"`
+	Options polycode.TaskOptions `json:"options"`
+	Input   any                  `json:"input"`
+}
+
+type CompensationOutcome struct {
+	Service string       `json:"service"`
+	Method  string       `json:"method"`
+	IsError bool         `json:"isError"`
+	Error   errors.Error `json:"error"`
+}
+
+type SagaOutcome struct {
+	Name          string                `json:"name"`
+	Reason        errors.Error          `json:"reason"`
+	Compensations []CompensationOutcome `json:"compensations"`
+}
+
+// Saga tracks the compensations of completed workflow steps. Steps and compensations are plain
+// service calls, so on re-execution the sidecar replays them in the same order and the saga is
+// rebuilt to the point where the workflow was suspended.
+type Saga struct {
+	ctx           context.Context
+	sessionId     string
+	name          string
+	serviceClient ServiceClient
+	compensations []Compensation
+	compensated   bool
+}
+
+// Step calls the service method and registers the compensation if the call succeeds
+func (s *Saga) Step(options polycode.TaskOptions, service string, method string, input any, compensation Compensation) polycode.Response {
+	return s.StepEx("", options, service, method, input, compensation)
+}
+
+// StepEx is like Step but calls the service in the given environment, the compensation runs in its own EnvId
+func (s *Saga) StepEx(envId string, options polycode.TaskOptions, service string, method string, input any, compensation Compensation) polycode.Response {
+	res := s.service(envId, service).RequestReply(options, method, input)
+	if !res.IsError() {
+		s.AddCompensation(compensation)
+	}
+	return res
+}
+
+// AddCompensation registers a compensation for a step executed outside the saga
+func (s *Saga) AddCompensation(compensation Compensation) {
+	s.compensations = append(s.compensations, compensation)
+}
+
+// Abort runs the compensations and returns an error carrying the abort reason, which may be nil
+func (s *Saga) Abort(reason error) error {
+	outcome, err := s.compensate(reason)
+	if err != nil {
+		// keep the abort reason next to the compensation failure
+		if reason != nil {
+			err = fmt.Errorf("%w: %w", reason, err)
+		}
+		return ErrSagaAborted.With(s.name).Wrap(err)
+	}
+
+	fmt.Printf("client: saga %s aborted, %d compensations executed\n", s.name, len(outcome.Compensations))
+	if reason == nil {
+		return ErrSagaAborted.With(s.name)
+	}
+	return ErrSagaAborted.With(s.name).Wrap(reason)
+}
+
+// Compensate runs the registered compensations in reverse order. Every compensation is attempted
+// even if an earlier one fails, and the outcome is recorded once per saga.
+func (s *Saga) Compensate() (SagaOutcome, error) {
+	return s.compensate(nil)
+}
+
+func (s *Saga) compensate(reason error) (SagaOutcome, error) {
+	if s.compensated {
+		return SagaOutcome{}, ErrSagaCompensated.With(s.name)
+	}
+	s.compensated = true
+
+	// compensations also run for a cancelled workflow
+	defer Invocations.Detach(s.sessionId)()
+
+	outcome := SagaOutcome{
+		Name:          s.name,
+		Compensations: make([]CompensationOutcome, 0, len(s.compensations)),
+	}
+	if reason != nil {
+		outcome.Reason = ErrSagaAborted.With(s.name).Wrap(reason)
+	}
+
+	failed := false
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		c := s.compensations[i]
+		res := s.service(c.EnvId, c.Service).RequestReply(c.Options, c.Method, c.Input)
+
+		co := CompensationOutcome{
+			Service: c.Service,
+			Method:  c.Method,
+			IsError: res.IsError(),
+		}
+		if res.IsError() {
+			failed = true
+			_, err := res.GetAny()
+			co.Error = ErrTaskExecError.Wrap(err)
+			fmt.Printf("client: saga %s compensation %s.%s failed: %v\n", s.name, c.Service, c.Method, err)
+		}
+		outcome.Compensations = append(outcome.Compensations, co)
+	}
+
+	memo := Memo{
+		ctx:           s.ctx,
+		sessionId:     s.sessionId,
+		serviceClient: s.serviceClient,
+		key:           "saga:" + s.name,
+		getter: func() (any, error) {
+			return outcome, nil
+		},
+	}
+
+	err := memo.Get().Get(&outcome)
+	if err != nil {
+		fmt.Printf("client: saga %s outcome record error: %v\n", s.name, err)
+		return outcome, err
+	}
+
+	if failed {
+		return outcome, ErrSagaCompensationFailed.With(s.name)
+	}
+
+	return outcome, nil
+}
+
+func (s *Saga) service(envId string, service string) Service {
+	return Service{
+		ctx:           s.ctx,
+		sessionId:     s.sessionId,
+		envId:         envId,
+		service:       service,
+		serviceClient: s.serviceClient,
+	}
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+type sagaServiceClient struct {
+	*mockServiceClient
+	calls  []string
+	envIds []string
+	failOn string
+}
+
+func (m *sagaServiceClient) ExecService(sessionId string, req ExecServiceRequest) (ExecServiceResponse, error) {
+	call := req.Service + "." + req.Method
+	m.calls = append(m.calls, call)
+	m.envIds = append(m.envIds, req.EnvId)
+	if call == m.failOn {
+		return ExecServiceResponse{IsError: true, Error: ErrServiceExecError}, nil
+	}
+	return ExecServiceResponse{Output: "ok"}, nil
+}
+
+func TestSaga_AbortCompensatesInReverseOrder(t *testing.T) {
+	mock := &sagaServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		failOn:            "shipping.Ship",
+	}
+
+	s := &Saga{
+		ctx:           context.Background(),
+		sessionId:     "sess-1",
+		name:          "order",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	s.Step(opts, "payment", "Charge", nil, Compensation{Service: "payment", Method: "Refund"})
+	s.Step(opts, "inventory", "Reserve", nil, Compensation{Service: "inventory", Method: "Release"})
+	res := s.Step(opts, "shipping", "Ship", nil, Compensation{Service: "shipping", Method: "Cancel"})
+	if !res.IsError() {
+		t.Fatalf("expected shipping step to fail")
+	}
+
+	if err := s.Abort(ErrServiceExecError); err == nil {
+		t.Fatalf("expected abort error")
+	}
+
+	want := []string{"payment.Charge", "inventory.Reserve", "shipping.Ship", "inventory.Release", "payment.Refund"}
+	if len(mock.calls) != len(want) {
+		t.Fatalf("unexpected calls %v", mock.calls)
+	}
+	for i := range want {
+		if mock.calls[i] != want[i] {
+			t.Fatalf("unexpected calls %v, want %v", mock.calls, want)
+		}
+	}
+
+	if _, err := s.Compensate(); err == nil {
+		t.Fatalf("expected second compensation to be rejected")
+	}
+}
+
+func TestSaga_StepUsesItsOwnEnvId(t *testing.T) {
+	mock := &sagaServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		failOn:            "billing.Refund",
+	}
+
+	s := &Saga{
+		ctx:           context.Background(),
+		sessionId:     "sess-1",
+		name:          "order",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	s.StepEx("env-forward", opts, "billing", "Charge", nil, Compensation{EnvId: "env-undo", Service: "billing", Method: "Refund"})
+
+	if err := s.Abort(ErrServiceExecError); err == nil {
+		t.Fatalf("expected abort error")
+	}
+
+	if len(mock.envIds) != 2 || mock.envIds[0] != "env-forward" || mock.envIds[1] != "env-undo" {
+		t.Fatalf("unexpected env ids %v", mock.envIds)
+	}
+}
+
+func TestSaga_AbortWithoutReason(t *testing.T) {
+	mock := &sagaServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		failOn:            "payment.Refund",
+	}
+
+	s := &Saga{
+		ctx:           context.Background(),
+		sessionId:     "sess-3",
+		name:          "order",
+		serviceClient: mock,
+	}
+
+	var opts polycode.TaskOptions
+	s.Step(opts, "payment", "Charge", nil, Compensation{Service: "payment", Method: "Refund"})
+
+	err := s.Abort(nil)
+	if !errors.Is(err, ErrSagaAborted) || !errors.Is(err, ErrSagaCompensationFailed) {
+		t.Fatalf("expected the compensation failure to be wrapped, got %v", err)
+	}
+	if strings.Contains(err.Error(), "%!") {
+		t.Fatalf("unexpected format verb in %q", err.Error())
+	}
+}
@@ -65,6 +65,12 @@ func (r Service) RequestReply(options polycode.TaskOptions, method string, input
 }
 
 func (r Service) Send(options polycode.TaskOptions, method string, input any) error {
+	_, err := r.Start(options, method, input)
+	return err
+}
+
+// Start starts the service method as a child task without waiting for it and returns a handle to the task
+func (r Service) Start(options polycode.TaskOptions, method string, input any) (TaskHandle, error) {
 	req := ExecServiceRequest{
 		EnvId:         r.envId,
 		Service:       r.service,
@@ -77,13 +83,18 @@ func (r Service) Send(options polycode.TaskOptions, method string, input any) er
 	output, err := r.serviceClient.ExecService(r.sessionId, req)
 	if err != nil {
 		fmt.Printf("client: exec task error: %v\n", err)
-		return ErrTaskExecError.Wrap(err)
+		return TaskHandle{}, ErrTaskExecError.Wrap(err)
 	}
 
 	fmt.Printf("client: exec task output: %v\n", output)
 	if output.IsError {
-		return output.Error
-	} else {
-		return nil
+		return TaskHandle{}, output.Error
 	}
+
+	return TaskHandle{
+		ctx:           r.ctx,
+		sessionId:     r.sessionId,
+		taskId:        output.TaskId,
+		serviceClient: r.serviceClient,
+	}, nil
 }
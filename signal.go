@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"github.com/cloudimpl/polycode-sdk-go/errors"
+	"time"
+)
+
+type Signal struct {
+	ctx           context.Context
+	sessionId     string
+	name          string
+	serviceClient ServiceClient
+}
+
+func (s Signal) Name() string {
+	return s.name
+}
+
+// Await blocks until the signal is emitted to the current task
+func (s Signal) Await() polycode.Response {
+	return s.AwaitWithTimeout(0)
+}
+
+// AwaitWithTimeout blocks until the signal is emitted or the timeout expires.
+// A zero timeout waits forever.
+func (s Signal) AwaitWithTimeout(timeout time.Duration) polycode.Response {
+	req := SignalWaitRequest{
+		SignalName: s.name,
+		Timeout:    timeout.Milliseconds(),
+	}
+
+	output, err := s.serviceClient.WaitForSignal(s.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: wait for signal error: %v\n", err)
+		return Response{
+			output:  nil,
+			isError: true,
+			error:   ErrTaskExecError.Wrap(err),
+		}
+	}
+
+	if output.IsTimeout {
+		fmt.Printf("client: wait for signal %s timed out\n", s.name)
+		return Response{
+			output:  nil,
+			isError: true,
+			error:   ErrSignalTimeout.With(s.name),
+		}
+	}
+
+	return Response{
+		output:  output.Output,
+		isError: output.IsError,
+		error:   output.Error,
+	}
+}
+
+// EmitValue emits the signal with the given payload to the task identified by taskId
+func (s Signal) EmitValue(taskId string, data any) error {
+	req := SignalEmitRequest{
+		TaskId:     taskId,
+		SignalName: s.name,
+		Output:     data,
+		IsError:    false,
+		Error:      errors.Error{},
+	}
+
+	err := s.serviceClient.EmitSignal(s.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: emit signal error: %v\n", err)
+		return ErrTaskExecError.Wrap(err)
+	}
+
+	return nil
+}
+
+// EmitError emits the signal as a failure to the task identified by taskId
+func (s Signal) EmitError(taskId string, err errors.Error) error {
+	req := SignalEmitRequest{
+		TaskId:     taskId,
+		SignalName: s.name,
+		Output:     nil,
+		IsError:    true,
+		Error:      err,
+	}
+
+	err2 := s.serviceClient.EmitSignal(s.sessionId, req)
+	if err2 != nil {
+		fmt.Printf("client: emit signal error: %v\n", err2)
+		return ErrTaskExecError.Wrap(err2)
+	}
+
+	return nil
+}
+
+type SignalSelector struct {
+	ctx           context.Context
+	sessionId     string
+	names         []string
+	serviceClient ServiceClient
+}
+
+// Select blocks until any of the signals is emitted or the timeout expires and
+// returns the name of the signal that fired. A zero timeout waits forever.
+func (s SignalSelector) Select(timeout time.Duration) (string, polycode.Response) {
+	req := SignalSelectRequest{
+		SignalNames: s.names,
+		Timeout:     timeout.Milliseconds(),
+	}
+
+	output, err := s.serviceClient.SelectSignal(s.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: select signal error: %v\n", err)
+		return "", Response{
+			output:  nil,
+			isError: true,
+			error:   ErrTaskExecError.Wrap(err),
+		}
+	}
+
+	if output.IsTimeout {
+		fmt.Printf("client: select signal %v timed out\n", s.names)
+		return "", Response{
+			output:  nil,
+			isError: true,
+			error:   ErrSignalTimeout.With(fmt.Sprintf("%v", s.names)),
+		}
+	}
+
+	return output.SignalName, Response{
+		output:  output.Output,
+		isError: output.IsError,
+		error:   output.Error,
+	}
+}
+
+type timeoutAwaiter interface {
+	AwaitWithTimeout(timeout time.Duration) polycode.Response
+}
+
+// AwaitSignal waits for the signal and decodes its payload into T. A non-zero timeout
+// fails with ErrSignalTimeoutUnsupported if the signal cannot wait with a timeout.
+func AwaitSignal[T any](signal polycode.Signal, timeout time.Duration) (T, error) {
+	var ret T
+
+	var res polycode.Response
+	if s, ok := signal.(timeoutAwaiter); ok {
+		res = s.AwaitWithTimeout(timeout)
+	} else if timeout == 0 {
+		res = signal.Await()
+	} else {
+		return ret, ErrSignalTimeoutUnsupported.With(fmt.Sprintf("%T", signal))
+	}
+
+	err := res.Get(&ret)
+	return ret, err
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+	"github.com/cloudimpl/polycode-sdk-go/errors"
+)
+
+// signalServiceClient answers signal waits with a canned response and records the calls
+type signalServiceClient struct {
+	*mockServiceClient
+	waited  SignalWaitRequest
+	emitted SignalEmitRequest
+	out     SignalWaitResponse
+}
+
+func (c *signalServiceClient) WaitForSignal(sessionId string, req SignalWaitRequest) (SignalWaitResponse, error) {
+	c.waited = req
+	return c.out, nil
+}
+
+func (c *signalServiceClient) EmitSignal(sessionId string, req SignalEmitRequest) error {
+	c.emitted = req
+	return nil
+}
+
+// awaitOnlySignal is a polycode.Signal that cannot wait with a timeout
+type awaitOnlySignal struct{}
+
+func (awaitOnlySignal) Await() polycode.Response {
+	return Response{output: "approved"}
+}
+
+func (awaitOnlySignal) EmitValue(string, any) error { return nil }
+
+func (awaitOnlySignal) EmitError(string, errors.Error) error { return nil }
+
+func TestSignal_Await(t *testing.T) {
+	client := &signalServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out:               SignalWaitResponse{Output: "approved"},
+	}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var out string
+	if err := ctx.Signal("approval").Await().Get(&out); err != nil || out != "approved" {
+		t.Fatalf("unexpected signal value %q (err=%v)", out, err)
+	}
+	if client.waited.SignalName != "approval" || client.waited.Timeout != 0 {
+		t.Fatalf("unexpected wait request %+v", client.waited)
+	}
+}
+
+func TestSignal_AwaitWithTimeout(t *testing.T) {
+	client := &signalServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		out:               SignalWaitResponse{IsTimeout: true},
+	}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	res := ctx.Signal("approval").(Signal).AwaitWithTimeout(2 * time.Second)
+	if !res.IsError() {
+		t.Fatalf("expected a timeout error")
+	}
+	if client.waited.Timeout != 2000 {
+		t.Fatalf("expected timeout in milliseconds, got %+v", client.waited)
+	}
+
+	if _, err := AwaitSignal[string](ctx.Signal("approval"), time.Second); err == nil {
+		t.Fatalf("expected typed await to report the timeout")
+	}
+}
+
+func TestSignal_EmitValue(t *testing.T) {
+	client := &signalServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	if err := ctx.Signal("approval").EmitValue("task-1", "yes"); err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if client.emitted.TaskId != "task-1" || client.emitted.SignalName != "approval" ||
+		client.emitted.Output != "yes" || client.emitted.IsError {
+		t.Fatalf("unexpected emit request %+v", client.emitted)
+	}
+
+	if err := ctx.Signal("approval").EmitError("task-1", errors.Error{}); err != nil || !client.emitted.IsError {
+		t.Fatalf("unexpected error emit %+v (err=%v)", client.emitted, err)
+	}
+}
+
+func TestSignalSelector_Select(t *testing.T) {
+	server := httptest.NewServer(newMockBackendRouter())
+	defer server.Close()
+
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: NewServiceClient(server.URL)}
+
+	name, res := ctx.SelectSignal("signal-a", "signal-b").Select(time.Second)
+	var out string
+	if err := res.Get(&out); err != nil || name != "signal-a" || out != "signal-output" {
+		t.Fatalf("unexpected selected signal %q value %q (err=%v)", name, out, err)
+	}
+}
+
+func TestAwaitSignal_TimeoutUnsupported(t *testing.T) {
+	out, err := AwaitSignal[string](awaitOnlySignal{}, 0)
+	if err != nil || out != "approved" {
+		t.Fatalf("unexpected value %q (err=%v)", out, err)
+	}
+
+	if _, err = AwaitSignal[string](awaitOnlySignal{}, time.Second); err == nil {
+		t.Fatalf("expected an error when the timeout cannot be applied")
+	}
+}
@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+const (
+	TaskPending TaskStatus = iota
+	TaskRunning
+	TaskCompleted
+	TaskFailed
+	TaskCancelled
+)
+
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskPending:
+		return "pending"
+	case TaskRunning:
+		return "running"
+	case TaskCompleted:
+		return "completed"
+	case TaskFailed:
+		return "failed"
+	case TaskCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("unknown(%d)", int8(s))
+	}
+}
+
+// TaskStarter is implemented by the service and app call targets of the Context, it starts a method as a
+// child task and returns a handle to it
+type TaskStarter interface {
+	Start(options polycode.TaskOptions, method string, input any) (TaskHandle, error)
+}
+
+// AgentTaskStarter is implemented by the agent call targets of the Context
+type AgentTaskStarter interface {
+	Start(options polycode.TaskOptions, input polycode.AgentInput) (TaskHandle, error)
+}
+
+// StartTask starts the method of a service or app returned by the Context as a child task, like Send but
+// returning a handle to the started task
+func StartTask(target polycode.Service, options polycode.TaskOptions, method string, input any) (TaskHandle, error) {
+	starter, ok := target.(TaskStarter)
+	if !ok {
+		return TaskHandle{}, errors.New("client: call target cannot start child tasks")
+	}
+
+	return starter.Start(options, method, input)
+}
+
+// StartAgentTask calls an agent returned by the Context as a child task and returns a handle to it
+func StartAgentTask(target polycode.Agent, options polycode.TaskOptions, input polycode.AgentInput) (TaskHandle, error) {
+	starter, ok := target.(AgentTaskStarter)
+	if !ok {
+		return TaskHandle{}, errors.New("client: agent cannot start child tasks")
+	}
+
+	return starter.Start(options, input)
+}
+
+// TaskHandle refers to a child task started with StartTask, StartAgentTask or the Start method of a call target
+type TaskHandle struct {
+	ctx           context.Context
+	sessionId     string
+	taskId        string
+	serviceClient ServiceClient
+}
+
+func (h TaskHandle) TaskId() string {
+	return h.taskId
+}
+
+// Await suspends the workflow until the child task completes and returns its result
+func (h TaskHandle) Await() polycode.Response {
+	req := TaskAwaitRequest{
+		TaskId: h.taskId,
+	}
+
+	output, err := h.serviceClient.AwaitTask(h.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: await task error: %v\n", err)
+		return Response{
+			output:  nil,
+			isError: true,
+			error:   ErrTaskExecError.Wrap(err),
+		}
+	}
+
+	return Response{
+		output:  output.Output,
+		isError: output.IsError,
+		error:   output.Error,
+	}
+}
+
+// Status returns the current status of the child task without waiting for it. The status is recorded
+// under the memo key "task-status:" + task id, so a re-execution sees the statuses the first execution
+// saw, in the same order.
+func (h TaskHandle) Status() (TaskStatus, error) {
+	memo := Memo{
+		ctx:           h.ctx,
+		sessionId:     h.sessionId,
+		serviceClient: h.serviceClient,
+		key:           "task-status:" + h.taskId,
+		getter: func() (any, error) {
+			req := TaskStatusRequest{
+				TaskId: h.taskId,
+			}
+
+			output, err := h.serviceClient.GetTaskStatus(h.sessionId, req)
+			if err != nil {
+				fmt.Printf("client: get task status error: %v\n", err)
+				return nil, err
+			}
+			return output.Status, nil
+		},
+	}
+
+	var status TaskStatus
+	if err := memo.Get().Get(&status); err != nil {
+		return TaskPending, err
+	}
+	return status, nil
+}
+
+// Cancel requests cancellation of the child task, the child observes it through its Context.Done()
+func (h TaskHandle) Cancel(reason string) error {
+	req := TaskCancelRequest{
+		TaskId: h.taskId,
+		Reason: reason,
+	}
+
+	err := h.serviceClient.CancelTask(h.sessionId, req)
+	if err != nil {
+		fmt.Printf("client: cancel task error: %v\n", err)
+		return ErrTaskExecError.Wrap(err)
+	}
+
+	return nil
+}
+
+// Signal emits a signal with the given payload to the child task
+func (h TaskHandle) Signal(signalName string, data any) error {
+	return Signal{
+		ctx:           h.ctx,
+		sessionId:     h.sessionId,
+		name:          signalName,
+		serviceClient: h.serviceClient,
+	}.EmitValue(h.taskId, data)
+}
@@ -0,0 +1,152 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudimpl/polycode-sdk-go"
+)
+
+// taskServiceClient starts child tasks and records the calls made through their handles
+type taskServiceClient struct {
+	*mockServiceClient
+	started   []string
+	awaited   TaskAwaitRequest
+	cancelled TaskCancelRequest
+	signalled SignalEmitRequest
+}
+
+func (c *taskServiceClient) ExecService(sessionId string, req ExecServiceRequest) (ExecServiceResponse, error) {
+	if !req.FireAndForget {
+		c.t.Fatalf("expected a fire and forget call, got %+v", req)
+	}
+	c.started = append(c.started, req.Service+"."+req.Method)
+	return ExecServiceResponse{TaskId: "task-" + req.Method}, nil
+}
+
+func (c *taskServiceClient) ExecApp(sessionId string, req ExecAppRequest) (ExecAppResponse, error) {
+	if !req.FireAndForget {
+		c.t.Fatalf("expected a fire and forget call, got %+v", req)
+	}
+	c.started = append(c.started, req.AppName+"."+req.Method)
+	return ExecAppResponse{TaskId: "task-" + req.Method}, nil
+}
+
+func (c *taskServiceClient) AwaitTask(sessionId string, req TaskAwaitRequest) (TaskAwaitResponse, error) {
+	c.awaited = req
+	return TaskAwaitResponse{Output: "shipped"}, nil
+}
+
+func (c *taskServiceClient) GetTaskStatus(sessionId string, req TaskStatusRequest) (TaskStatusResponse, error) {
+	return TaskStatusResponse{Status: TaskRunning}, nil
+}
+
+func (c *taskServiceClient) CancelTask(sessionId string, req TaskCancelRequest) error {
+	c.cancelled = req
+	return nil
+}
+
+func (c *taskServiceClient) EmitSignal(sessionId string, req SignalEmitRequest) error {
+	c.signalled = req
+	return nil
+}
+
+func TestStartTask_AllCallTargets(t *testing.T) {
+	client := &taskServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var opts polycode.TaskOptions
+	service, err := StartTask(ctx.Service("shipping").Get(), opts, "Ship", nil)
+	if err != nil || service.TaskId() != "task-Ship" {
+		t.Fatalf("unexpected service task %v (err=%v)", service.TaskId(), err)
+	}
+
+	app, err := StartTask(ctx.AppEx("env-2", "billing"), opts, "Invoice", nil)
+	if err != nil || app.TaskId() != "task-Invoice" {
+		t.Fatalf("unexpected app task %v (err=%v)", app.TaskId(), err)
+	}
+
+	agent, err := StartAgentTask(ctx.Agent("planner").Get(), opts, polycode.AgentInput{SessionKey: "s"})
+	if err != nil || agent.TaskId() != "task-CallAgent" {
+		t.Fatalf("unexpected agent task %v (err=%v)", agent.TaskId(), err)
+	}
+
+	want := []string{"shipping.Ship", "billing.Invoice", "agent-service.CallAgent"}
+	for i := range want {
+		if client.started[i] != want[i] {
+			t.Fatalf("unexpected calls %v, want %v", client.started, want)
+		}
+	}
+}
+
+func TestTaskHandle_AwaitStatusCancelSignal(t *testing.T) {
+	client := &taskServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	ctx := Context{ctx: context.Background(), sessionId: "sess-1", client: client}
+
+	var opts polycode.TaskOptions
+	handle, err := StartTask(ctx.Service("shipping").Get(), opts, "Ship", nil)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	status, err := handle.Status()
+	if err != nil || status != TaskRunning {
+		t.Fatalf("unexpected status %v (err=%v)", status, err)
+	}
+
+	if err = handle.Signal("approve", "yes"); err != nil {
+		t.Fatalf("signal failed: %v", err)
+	}
+	if client.signalled.TaskId != "task-Ship" || client.signalled.SignalName != "approve" || client.signalled.Output != "yes" {
+		t.Fatalf("unexpected signal %+v", client.signalled)
+	}
+
+	if err = handle.Cancel("no longer needed"); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if client.cancelled.TaskId != "task-Ship" || client.cancelled.Reason != "no longer needed" {
+		t.Fatalf("unexpected cancel %+v", client.cancelled)
+	}
+
+	var out string
+	if err = handle.Await().Get(&out); err != nil || out != "shipped" {
+		t.Fatalf("unexpected await result %q (err=%v)", out, err)
+	}
+	if client.awaited.TaskId != "task-Ship" {
+		t.Fatalf("unexpected await %+v", client.awaited)
+	}
+}
+
+// statusServiceClient records the status memos of a task and counts the live status queries
+type statusServiceClient struct {
+	*memoServiceClient
+	queried int
+}
+
+func (c *statusServiceClient) GetTaskStatus(sessionId string, req TaskStatusRequest) (TaskStatusResponse, error) {
+	c.queried++
+	return TaskStatusResponse{Status: TaskRunning}, nil
+}
+
+func TestTaskHandle_StatusReplaysRecordedStatus(t *testing.T) {
+	client := &statusServiceClient{memoServiceClient: &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}}
+	handle := TaskHandle{ctx: context.Background(), sessionId: "sess-1", taskId: "task-1", serviceClient: client}
+
+	status, err := handle.Status()
+	if err != nil || status != TaskRunning {
+		t.Fatalf("unexpected status %v (err=%v)", status, err)
+	}
+	if len(client.results) != 1 || client.results[0].Key != "task-status:task-1" {
+		t.Fatalf("expected the status to be recorded, got %+v", client.results)
+	}
+
+	// the task completed meanwhile, a re-execution still sees the recorded status
+	client.recorded = &ExecFuncResponse{IsCompleted: true, Key: "task-status:task-1", Output: float64(TaskRunning)}
+	status, err = handle.Status()
+	if err != nil || status != TaskRunning {
+		t.Fatalf("unexpected replayed status %v (err=%v)", status, err)
+	}
+	if client.queried != 1 {
+		t.Fatalf("expected the replay not to query the sidecar, got %d queries", client.queried)
+	}
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudimpl/polycode-sdk-go"
+	"sync"
+	"time"
+)
+
+// SessionTempCleanupTimeout bounds the deletion of the session temp files once a task completes
+const SessionTempCleanupTimeout = 10 * time.Second
+
+func sessionTempFolder(client ServiceClient, sessionId string) Folder {
+	root := Folder{
+		sessionId: sessionId,
+		client:    client,
+		parent:    nil,
+		name:      "temp-files",
+		temp:      true,
+	}
+
+	return root.Folder("sessions").(Folder).Folder(sessionId).(Folder)
+}
+
+// sessionTempUsed holds the sessions whose temp folder was handed out by the running invocation
+var sessionTempUsed sync.Map
+
+func markSessionTempUsed(sessionId string) {
+	sessionTempUsed.Store(sessionId, struct{}{})
+}
+
+// finishSessionTempFiles is called once an invocation returns and cleans up the session temp folder
+// in the background if the task reached a terminal state. Only a folder used by this execution is
+// deleted: a re-execution runs the handler again and uses the folder again, files of an execution
+// on another replica that is not repeated here expire with their TTL.
+func finishSessionTempFiles(client ServiceClient, sessionId string, terminal bool) {
+	if _, used := sessionTempUsed.LoadAndDelete(sessionId); used && terminal {
+		go cleanupSessionTempFiles(client, sessionId)
+	}
+}
+
+// cleanupSessionTempFiles deletes the session temp folder of a task that reached a terminal state. The
+// sidecar calls are bound by SessionTempCleanupTimeout and failures are only logged, as the files still
+// expire with their TTL.
+func cleanupSessionTempFiles(client ServiceClient, sessionId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), SessionTempCleanupTimeout)
+	defer cancel()
+
+	_, release := Invocations.Register(ctx, sessionId)
+	defer release()
+
+	err := sessionTempFolder(client, sessionId).Delete()
+	if err != nil {
+		fmt.Printf("client: failed to clean up temp files of session %s: %s\n", sessionId, err.Error())
+	}
+}
+
+func isTempFolder(folder polycode.Folder) bool {
+	f, ok := folder.(Folder)
+	return ok && f.temp
+}
+
+// checkAttributes rejects a TTL on a file of the permanent file store
+func (f File) checkAttributes(attributes FileAttributes) error {
+	if attributes.TTL != 0 && !f.temp {
+		return ErrNotTempFile.With(f.Path())
+	}
+
+	if attributes.TTL < 0 {
+		return errors.New("client: negative temp file ttl")
+	}
+
+	return nil
+}
+
+// Promote moves this temp file into dest in the permanent file store in one step on the file store
+// side, the file no longer expires and is not removed by the session cleanup
+func (f File) Promote(dest polycode.Folder) (polycode.File, error) {
+	if !f.temp {
+		return nil, ErrNotTempFile.With(f.Path())
+	}
+
+	if isTempFolder(dest) {
+		return nil, errors.New("client: promote destination is in the temp file store")
+	}
+
+	req := PromoteFileRequest{
+		Key:     f.Path(),
+		DestKey: dest.Path() + "/" + f.name,
+	}
+
+	err := f.client.PromoteFile(f.sessionId, req)
+	if err != nil {
+		fmt.Printf("failed to promote file: %s\n", err.Error())
+		return nil, err
+	}
+
+	return dest.File(f.name), nil
+}
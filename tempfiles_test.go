@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile_TempFileTTLAndPromote(t *testing.T) {
+	client := newFileServiceClient(nil)
+	ctx := Context{sessionId: "s1", client: client}
+
+	tmp := ctx.TempFileStore().Folder("uploads").File("draft.pdf").(File)
+	if err := tmp.SaveWithAttributes([]byte("draft"), FileAttributes{TTL: time.Hour}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !client.put.TempFile || client.put.TTL != time.Hour.Milliseconds() {
+		t.Fatalf("unexpected put request %+v", client.put)
+	}
+
+	permanent := ctx.FileStore().File("final.pdf").(File)
+	if err := permanent.SaveWithAttributes([]byte("final"), FileAttributes{TTL: time.Hour}); err == nil {
+		t.Fatalf("expected ttl on a permanent file to be rejected")
+	}
+
+	promoted, err := tmp.Promote(ctx.FileStore().Folder("reports"))
+	if err != nil {
+		t.Fatalf("promote failed: %v", err)
+	}
+	if client.promoted.Key != "temp-files/uploads/draft.pdf" || client.promoted.DestKey != "files/reports/draft.pdf" {
+		t.Fatalf("unexpected promote request %+v", client.promoted)
+	}
+	if promoted.Path() != "files/reports/draft.pdf" {
+		t.Fatalf("unexpected promoted path %s", promoted.Path())
+	}
+
+	if _, err := permanent.Promote(ctx.FileStore()); err == nil {
+		t.Fatalf("expected promoting a permanent file to fail")
+	}
+	if _, err := tmp.Promote(ctx.TempFileStore()); err == nil {
+		t.Fatalf("expected promoting into the temp store to fail")
+	}
+}
+
+// deadlineListServiceClient records whether sidecar calls of the session were bounded by a deadline
+type deadlineListServiceClient struct {
+	*fileServiceClient
+	bounded bool
+}
+
+func (c *deadlineListServiceClient) ListFile(sessionId string, req ListFilePageRequest) (ListFilePageResponse, error) {
+	_, c.bounded = Invocations.Context(sessionId).Deadline()
+	return c.fileServiceClient.ListFile(sessionId, req)
+}
+
+func TestCleanupSessionTempFiles(t *testing.T) {
+	client := &deadlineListServiceClient{
+		fileServiceClient: newFileServiceClient(map[string]string{
+			"temp-files/sessions/s1/a.txt":        "",
+			"temp-files/sessions/s1/nested/b.txt": "",
+			"temp-files/sessions/s2/c.txt":        "",
+		}),
+	}
+
+	ctx := Context{sessionId: "s1", client: client}
+	if path := ctx.SessionTempFileStore().Path(); path != "temp-files/sessions/s1" {
+		t.Fatalf("unexpected session temp path %s", path)
+	}
+
+	cleanupSessionTempFiles(client, "s1")
+	for _, key := range client.keys() {
+		if strings.HasPrefix(key, "temp-files/sessions/s1/") {
+			t.Fatalf("expected %s to be deleted", key)
+		}
+	}
+	if !client.exists("temp-files/sessions/s2/c.txt") {
+		t.Fatalf("expected files of other sessions to be kept")
+	}
+	if !client.bounded {
+		t.Fatalf("expected the cleanup to run with a deadline")
+	}
+	if _, ok := Invocations.Context("s1").Deadline(); ok {
+		t.Fatalf("expected the cleanup invocation to be released")
+	}
+}
+
+func TestFinishSessionTempFiles(t *testing.T) {
+	client := newFileServiceClient(map[string]string{
+		"temp-files/sessions/s3/a.txt": "",
+		"temp-files/sessions/s4/b.txt": "",
+	})
+
+	// a task that never used its session temp folder does not list it
+	finishSessionTempFiles(client, "s3", true)
+	if client.lists != 0 {
+		t.Fatalf("expected no cleanup, got %d list calls", client.lists)
+	}
+
+	// a suspended task keeps its files for the re-execution
+	Context{sessionId: "s4", client: client}.SessionTempFileStore()
+	finishSessionTempFiles(client, "s4", false)
+	if client.lists != 0 || !client.exists("temp-files/sessions/s4/b.txt") {
+		t.Fatalf("expected the files of a suspended task to be kept")
+	}
+
+	Context{sessionId: "s4", client: client}.SessionTempFileStore()
+	finishSessionTempFiles(client, "s4", true)
+
+	deadline := time.Now().Add(time.Second)
+	for client.exists("temp-files/sessions/s4/b.txt") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the session temp files to be cleaned up in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,8 +1,10 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/cloudimpl/polycode-sdk-go"
 	errors2 "github.com/cloudimpl/polycode-sdk-go/errors"
 	"github.com/cloudimpl/polycode-sdk-go/runtime"
@@ -10,6 +12,7 @@ import (
 	"github.com/invopop/jsonschema"
 	"log"
 	"reflect"
+	"time"
 )
 
 func ValueToServiceComplete(output any) ServiceCompleteEvent {
@@ -39,6 +42,60 @@ func ErrorToServiceComplete(err errors2.Error, stacktraceStr string) ServiceComp
 	}
 }
 
+func CancelledToServiceComplete(cause error) ServiceCompleteEvent {
+	reason := "cancelled"
+	if cause != nil {
+		reason = cause.Error()
+	}
+
+	return ServiceCompleteEvent{
+		Output:      nil,
+		IsError:     true,
+		IsCancelled: true,
+		Error:       ErrTaskCancelled.With(reason),
+	}
+}
+
+// OverloadedToServiceComplete reports an invocation rejected by the concurrency limiter, the sidecar may retry it later
+func OverloadedToServiceComplete(err error) ServiceCompleteEvent {
+	return ServiceCompleteEvent{
+		Output:      nil,
+		IsError:     true,
+		IsRetryable: true,
+		Error:       ErrOverloaded.Wrap(err),
+	}
+}
+
+func OverloadedToApiComplete(err error) ApiCompleteEvent {
+	return ApiCompleteEvent{
+		Response: polycode.ApiResponse{
+			StatusCode:      503,
+			Header:          map[string]string{"Retry-After": "1"},
+			Body:            ErrOverloaded.Wrap(err).ToJson(),
+			IsBase64Encoded: false,
+		},
+	}
+}
+
+func TimeoutToServiceComplete(deadline time.Time, stacktraceStr string) ServiceCompleteEvent {
+	evt := ErrorToServiceComplete(ErrTaskTimeout.With(deadline.Format(time.RFC3339Nano)), stacktraceStr)
+	evt.IsTimeout = true
+	return evt
+}
+
+// interruptedToServiceComplete reports a task whose context ended before it completed, either
+// because its deadline expired or because it was cancelled
+func interruptedToServiceComplete(ctx context.Context, event ServiceStartEvent, stacktraceStr string) ServiceCompleteEvent {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		deadline, _ := ctx.Deadline()
+		fmt.Printf("service timed out %s.%s\n", event.Service, event.Method)
+		return TimeoutToServiceComplete(deadline, stacktraceStr)
+	}
+
+	fmt.Printf("service cancelled %s.%s\n", event.Service, event.Method)
+	return CancelledToServiceComplete(context.Cause(ctx))
+}
+
 func ErrorToApiComplete(err errors2.Error) ApiCompleteEvent {
 	return ApiCompleteEvent{
 		Response: polycode.ApiResponse{
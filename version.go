@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultVersion is returned for executions that passed a change point before the change was introduced
+const DefaultVersion = -1
+
+// VersionDescription describes a change point in a workflow and the versions the code still supports
+type VersionDescription struct {
+	ChangeId   string `json:"changeId"`
+	MinVersion int    `json:"minVersion"`
+	MaxVersion int    `json:"maxVersion"`
+}
+
+// VersionUsage is a registered change point of a workflow method together with the versions recorded
+// for it by executions that have not reached a terminal state, suspended executions included
+type VersionUsage struct {
+	Method string `json:"method"`
+	VersionDescription
+	InFlight []int `json:"inFlight"`
+}
+
+// VersionReporter is implemented by ApiServer listeners that can report the versions in flight
+type VersionReporter interface {
+	VersionReport() ([]VersionUsage, error)
+}
+
+// VersionReport lists every registered change point with the versions still in flight. The in-flight
+// versions are read from the version markers the sidecar keeps for unfinished executions of the app, so
+// the report covers executions started by any instance of the app and before this process started.
+func (c ClientRuntime) VersionReport() ([]VersionUsage, error) {
+	res, err := c.client.ListVersionMarkers(VersionMarkersRequest{AppName: c.env.AppName})
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to list version markers: %w", err)
+	}
+
+	inFlight := make(map[string][]int)
+	for _, marker := range res.Markers {
+		key := marker.Method + "/" + marker.ChangeId
+		inFlight[key] = append(inFlight[key], marker.Version)
+	}
+
+	methods := make([]string, 0, len(c.versionMap))
+	for method := range c.versionMap {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	var report []VersionUsage
+	for _, method := range methods {
+		for _, version := range c.versionMap[method] {
+			versions := inFlight[method+"/"+version.ChangeId]
+			sort.Ints(versions)
+			report = append(report, VersionUsage{
+				Method:             method,
+				VersionDescription: version,
+				InFlight:           versions,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// GetVersion returns the version of the change point recorded for this execution. The first execution
+// that reaches the change point records maxVersion, while executions that are replaying history recorded
+// before the change point existed get DefaultVersion. Version markers are keyed by change id rather than
+// matched by position, so adding a change point does not shift the memo results of in-flight executions.
+//
+// minVersion must stay at DefaultVersion for as long as executions started before the change point may
+// still be in flight, otherwise their replay fails with ErrVersionNotSupported. The same applies to any
+// recorded version below minVersion once an old branch is removed, so raise minVersion only after
+// InFlight in the VersionReport of the method, also served at GET /v1/versions, no longer lists the
+// versions being dropped.
+func (c Context) GetVersion(changeId string, minVersion int, maxVersion int) int {
+	marker := VersionDescription{
+		ChangeId:   changeId,
+		MinVersion: minVersion,
+		MaxVersion: maxVersion,
+	}
+
+	req1 := ExecFuncRequest{
+		Key:   "version:" + changeId,
+		Input: marker,
+	}
+
+	res1, err := c.client.ExecFunc(c.sessionId, req1)
+	if err != nil {
+		fmt.Printf("client: exec func error: %v\n", err)
+		panic(ErrTaskExecError.Wrap(err))
+	}
+
+	version := maxVersion
+	if res1.IsCompleted {
+		err = ConvertType(res1.Output, &version)
+		if err != nil {
+			panic(ErrTaskExecError.Wrap(err))
+		}
+	} else {
+		if res1.IsReplaying {
+			version = DefaultVersion
+		}
+
+		req2 := ExecFuncResult{
+			Key:     req1.Key,
+			Input:   marker,
+			Output:  version,
+			IsError: false,
+		}
+
+		err = c.client.ExecFuncResult(c.sessionId, req2)
+		if err != nil {
+			fmt.Printf("client: exec func result error: %v\n", err)
+			panic(ErrTaskExecError.Wrap(err))
+		}
+	}
+
+	if version < minVersion || version > maxVersion {
+		panic(ErrVersionNotSupported.With(fmt.Sprintf("%s=%d, supported [%d, %d]", changeId, version, minVersion, maxVersion)))
+	}
+
+	return version
+}
@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+// markerServiceClient serves the version markers the sidecar recorded for unfinished executions
+type markerServiceClient struct {
+	*mockServiceClient
+	req     VersionMarkersRequest
+	markers []VersionMarker
+}
+
+func (c *markerServiceClient) ListVersionMarkers(req VersionMarkersRequest) (VersionMarkersResponse, error) {
+	c.req = req
+	return VersionMarkersResponse{Markers: c.markers}, nil
+}
+
+func TestGetVersion_RecordsMarker(t *testing.T) {
+	mock := &memoServiceClient{mockServiceClient: &mockServiceClient{t: t}}
+	c := Context{ctx: context.Background(), sessionId: "sess-v1", client: mock}
+
+	if v := c.GetVersion("new-tax", DefaultVersion, 1); v != 1 {
+		t.Fatalf("expected max version on first execution, got %d", v)
+	}
+	if len(mock.results) != 1 || mock.results[0].Key != "version:new-tax" {
+		t.Fatalf("expected marker recorded with its change id key, got %+v", mock.results)
+	}
+
+	// an execution replaying history from before the change point gets the default version
+	mock.recorded = &ExecFuncResponse{IsReplaying: true}
+	if v := c.GetVersion("new-tax", DefaultVersion, 1); v != DefaultVersion {
+		t.Fatalf("expected default version on replay, got %d", v)
+	}
+}
+
+func TestVersionReport_UsesSidecarMarkers(t *testing.T) {
+	client := &markerServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		markers: []VersionMarker{
+			{Method: "orders.Place", ChangeId: "new-tax", Version: 1, Executions: 3},
+			{Method: "orders.Place", ChangeId: "new-tax", Version: DefaultVersion, Executions: 1},
+			{Method: "orders.Cancel", ChangeId: "new-tax", Version: 2, Executions: 1},
+		},
+	}
+	rt := ClientRuntime{
+		env:    ClientEnv{AppName: "shop"},
+		client: client,
+		versionMap: map[string][]VersionDescription{
+			"orders.Place": {{ChangeId: "new-tax", MinVersion: DefaultVersion, MaxVersion: 1}},
+			"orders.Ship":  {{ChangeId: "carrier", MinVersion: 1, MaxVersion: 2}},
+		},
+	}
+
+	report, err := rt.VersionReport()
+	if err != nil {
+		t.Fatalf("version report failed: %v", err)
+	}
+	if client.req.AppName != "shop" {
+		t.Fatalf("unexpected request %+v", client.req)
+	}
+
+	if len(report) != 2 || report[0].Method != "orders.Place" || report[1].Method != "orders.Ship" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if inFlight := report[0].InFlight; len(inFlight) != 2 || inFlight[0] != DefaultVersion || inFlight[1] != 1 {
+		t.Fatalf("unexpected in-flight versions %v", inFlight)
+	}
+	if len(report[1].InFlight) != 0 {
+		t.Fatalf("expected no in-flight versions for an unused change point, got %v", report[1].InFlight)
+	}
+}
+
+func TestGetVersion_UnsupportedPanics(t *testing.T) {
+	mock := &memoServiceClient{
+		mockServiceClient: &mockServiceClient{t: t},
+		recorded:          &ExecFuncResponse{IsReplaying: true},
+	}
+	c := Context{ctx: context.Background(), sessionId: "sess-v3", client: mock}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected replay without marker to fail once the default version is dropped")
+		}
+	}()
+	c.GetVersion("new-tax", 1, 2)
+}